@@ -31,16 +31,22 @@ type Column struct {
 }
 
 func NewColumn(inputSize, height int) *Column {
+	return NewColumnWithConfig(inputSize, height, segment.DefaultPermanenceConfig)
+}
+
+// Creates a new column whose proximal and distal segments follow the given
+// permanence configuration.
+func NewColumnWithConfig(inputSize, height int, config segment.PermanenceConfiguration) *Column {
 	result := &Column{
 		active:         data.NewBitset(height),
 		predictive:     data.NewBitset(height),
-		proximal:       segment.NewDendriteSegment(inputSize),
+		proximal:       segment.NewDendriteSegmentWithConfig(inputSize, config),
 		learning:       -1,
 		learningTarget: 0,
 		distal:         make([]*segment.DistalSegmentGroup, height),
 	}
 	for i := 0; i < height; i++ {
-		result.distal[i] = segment.NewDistalSegmentGroup()
+		result.distal[i] = segment.NewDistalSegmentGroupWithConfig(config)
 	}
 	return result
 }
@@ -147,6 +153,15 @@ func (c *Column) AdaptSegments() {
 	}
 }
 
+// Forgets the learning cell and drops the pending segment updates, so that nothing
+// learned before a gap in the input is applied after it.
+func (c *Column) ResetLearning() {
+	c.learning = -1
+	for _, g := range c.distal {
+		g.ClearUpdates()
+	}
+}
+
 func (c *Column) LearnPrediction(state data.Bitset, minOverlap int) bool {
 	c.learning = -1
 	cell, sIndex, _ := c.FindBestSegment(state, minOverlap, false)
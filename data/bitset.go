@@ -7,6 +7,9 @@ package data
 import "fmt"
 import "bufio"
 import "io"
+import "math"
+import "math/bits"
+import "math/rand"
 import "strings"
 
 const deBrujin64 = 0x0218a392cd3d5dbf
@@ -74,41 +77,36 @@ func (b Bitset) Any(f func(int) bool) int {
 	return b.Len()
 }
 
-func (b Bitset) Overlap(other Bitset) int {
-	// This algorithm behaves well only when the number of set bits is small.
-	count := 0
-	for i, el := range b.binary {
-		v := el & other.binary[i]
-		for ; v != 0; count++ {
-			v &= v - 1
-		}
+// Counts the bits that are set in both bitsets.
+func (b Bitset) Overlap(other Bitset) (count int) {
+	if b.length != other.length {
+		panic(fmt.Errorf(
+			"Cannot overlap bitsets of different length (%d != %d)", b.length, other.length))
+	}
+	x, y := b.binary, other.binary[:len(b.binary)]
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		count += bits.OnesCount64(x[i]&y[i]) + bits.OnesCount64(x[i+1]&y[i+1]) +
+			bits.OnesCount64(x[i+2]&y[i+2]) + bits.OnesCount64(x[i+3]&y[i+3])
+	}
+	for ; i < len(x); i++ {
+		count += bits.OnesCount64(x[i] & y[i])
 	}
-	return count
+	return
 }
 
+// Counts the bits that are set. Uses the hardware population count where
+// available, so it is fast regardless of how dense the bitset is.
 func (b Bitset) NumSetBits() (count int) {
-	// This algorithm behaves well only when the number of set bits is small. If
-	// You are counting the non-zero bits in a dense bitset, use DenseCount()
-	// below.
 	for _, el := range b.binary {
-		for ; el != 0; count++ {
-			// Clear the least significant bit.
-			el &= el - 1
-		}
+		count += bits.OnesCount64(el)
 	}
 	return
 }
 
-func (b Bitset) DenseCount() (count int) {
-	// Only fast when the bitset is rather dense. Prefer NumSetBits() above in
-	// most cases.
-	for _, el := range b.binary {
-		el -= (el >> 1) & 0x5555555555555555
-		el = (el & 0x3333333333333333) + ((el >> 2) & 0x3333333333333333)
-		el = (((el + (el >> 4)) & 0xf0f0f0f0f0f0f0f) * 0x101010101010101) >> 56
-		count += int(el)
-	}
-	return
+// Deprecated: same as NumSetBits(), which is now fast for dense bitsets too.
+func (b Bitset) DenseCount() int {
+	return b.NumSetBits()
 }
 
 func (b Bitset) IsZero() bool {
@@ -221,8 +219,16 @@ func (b *Bitset) Or(other Bitset) {
 		panic(fmt.Errorf(
 			"Cannot OR bitsets of different length (%d != %d)", b.length, other.length))
 	}
-	for i, v := range other.binary {
-		b.binary[i] |= v
+	x, y := b.binary, other.binary[:len(b.binary)]
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		x[i] |= y[i]
+		x[i+1] |= y[i+1]
+		x[i+2] |= y[i+2]
+		x[i+3] |= y[i+3]
+	}
+	for ; i < len(x); i++ {
+		x[i] |= y[i]
 	}
 }
 
@@ -231,8 +237,16 @@ func (b *Bitset) And(other Bitset) {
 		panic(fmt.Errorf(
 			"Cannot AND bitsets of different length (%d != %d)", b.length, other.length))
 	}
-	for i, v := range other.binary {
-		b.binary[i] &= v
+	x, y := b.binary, other.binary[:len(b.binary)]
+	i := 0
+	for ; i+4 <= len(x); i += 4 {
+		x[i] &= y[i]
+		x[i+1] &= y[i+1]
+		x[i+2] &= y[i+2]
+		x[i+3] &= y[i+3]
+	}
+	for ; i < len(x); i++ {
+		x[i] &= y[i]
 	}
 }
 
@@ -246,6 +260,102 @@ func (b *Bitset) AndNot(other Bitset) {
 	}
 }
 
+func (b *Bitset) Xor(other Bitset) {
+	if b.length != other.length {
+		panic(fmt.Errorf(
+			"Cannot XOR bitsets of different length (%d != %d)", b.length, other.length))
+	}
+	for i, v := range other.binary {
+		b.binary[i] ^= v
+	}
+}
+
+// Flips every bit in [0, b.Len()).
+func (b *Bitset) Not() {
+	for i, v := range b.binary {
+		b.binary[i] = ^v
+	}
+	b.clearTail()
+}
+
+// Returns whether all bits set in this bitset are also set in the other.
+func (b Bitset) IsSubsetOf(other Bitset) bool {
+	if b.length != other.length {
+		panic(fmt.Errorf(
+			"Cannot compare bitsets of different length (%d != %d)", b.length, other.length))
+	}
+	for i, v := range b.binary {
+		if v&^other.binary[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Counts the bits that differ between the two bitsets.
+func (b Bitset) HammingDistance(other Bitset) (count int) {
+	if b.length != other.length {
+		panic(fmt.Errorf(
+			"Cannot compare bitsets of different length (%d != %d)", b.length, other.length))
+	}
+	for i, el := range b.binary {
+		count += bits.OnesCount64(el ^ other.binary[i])
+	}
+	return
+}
+
+// Returns the Jaccard similarity, i.e. the size of the intersection over the size
+// of the union. Two empty bitsets are identical, so their similarity is 1.
+func (b Bitset) Jaccard(other Bitset) float64 {
+	overlap := b.Overlap(other)
+	union := b.NumSetBits() + other.NumSetBits() - overlap
+	if union == 0 {
+		return 1.0
+	}
+	return float64(overlap) / float64(union)
+}
+
+// Returns the cosine similarity between the two bitsets as binary vectors. If
+// either bitset is empty, the similarity is 0.
+func (b Bitset) Cosine(other Bitset) float64 {
+	na, nb := b.NumSetBits(), other.NumSetBits()
+	if na == 0 || nb == 0 {
+		return 0.0
+	}
+	return float64(b.Overlap(other)) / math.Sqrt(float64(na)*float64(nb))
+}
+
+// Returns the indices of the set bits, in ascending order.
+func (b Bitset) Indices() []int {
+	result := make([]int, 0, b.NumSetBits())
+	b.Foreach(func(i int) {
+		result = append(result, i)
+	})
+	return result
+}
+
+// Creates a new bitset with k of the bits set in this bitset, picked uniformly at
+// random using the given source of randomness. If fewer than k bits are set, the
+// result is a copy of this bitset.
+func (b Bitset) Subsample(k int, rng *rand.Rand) *Bitset {
+	result := NewBitset(b.length)
+	if k <= 0 {
+		return result
+	}
+	// Reservoir sampling, so we iterate the bits only once.
+	reservoir := make([]int, 0, k)
+	seen := 0
+	b.Foreach(func(i int) {
+		if seen < k {
+			reservoir = append(reservoir, i)
+		} else if j := rng.Intn(seen + 1); j < k {
+			reservoir[j] = i
+		}
+		seen++
+	})
+	return result.Set(reservoir...)
+}
+
 func (b *Bitset) SetFromBitsetAt(other Bitset, offset int) {
 	if offset+other.length > b.length {
 		panic(fmt.Errorf("SetFromBitset() would go past end! Needs %d bits, has %d.",
@@ -265,6 +375,85 @@ func (b *Bitset) SetFromBitsetAt(other Bitset, offset int) {
 	}
 }
 
+// Creates a new bitset with the bits in the interval [start, end) of this bitset.
+// Bit start of this bitset is bit 0 of the result.
+func (b Bitset) Slice(start, end int) *Bitset {
+	if start < 0 || end > b.length || end < start {
+		panic(fmt.Errorf("Invalid slice [%d, %d) of bitset of length %d.",
+			start, end, b.length))
+	}
+	result := NewBitset(end - start)
+	src, shift := start/64, uint64(start%64)
+	for i := range result.binary {
+		if src+i >= len(b.binary) {
+			break
+		}
+		v := b.binary[src+i] >> shift
+		if shift != 0 && src+i+1 < len(b.binary) {
+			v |= b.binary[src+i+1] << (64 - shift)
+		}
+		result.binary[i] = v
+	}
+	result.clearTail()
+	return result
+}
+
+// Changes the length of this bitset to n bits. Growing adds unset bits at the end;
+// shrinking drops the bits past the new length. Panics if n is negative.
+func (b *Bitset) Resize(n int) *Bitset {
+	if n < 0 {
+		panic(fmt.Errorf("Cannot resize bitset to negative length %d.", n))
+	}
+	num := (n-1)/64 + 1
+	if num > cap(b.binary) {
+		binary := make([]uint64, num)
+		copy(binary, b.binary)
+		b.binary = binary
+	} else {
+		// Words past the new end stay in the slice capacity, so clear them when
+		// shrinking; growing clears them again anyway.
+		old := len(b.binary)
+		for i := num; i < old; i++ {
+			b.binary[i] = 0
+		}
+		b.binary = b.binary[:num]
+		for i := old; i < num; i++ {
+			b.binary[i] = 0
+		}
+	}
+	b.length = n
+	if n == 0 {
+		// An empty bitset still has one word, which clearTail() would keep whole.
+		b.binary[0] = 0
+	}
+	b.clearTail()
+	return b
+}
+
+// Unsets the bits in the last word that are past the end of this bitset.
+func (b *Bitset) clearTail() {
+	if rem := uint64(b.length % 64); rem != 0 {
+		b.binary[len(b.binary)-1] &= ^uint64(0) >> (64 - rem)
+	}
+}
+
+// Creates a new bitset with all the given bitsets side by side, in order.
+func Concat(bitsets ...Bitset) *Bitset {
+	length := 0
+	for _, b := range bitsets {
+		length += b.length
+	}
+	result := NewBitset(length)
+	offset := 0
+	for _, b := range bitsets {
+		if b.length > 0 {
+			result.SetFromBitsetAt(b, offset)
+		}
+		offset += b.length
+	}
+	return result
+}
+
 func (b Bitset) Print(width int, writer io.Writer) (err error) {
 	n := 0
 	buf := bufio.NewWriter(writer)
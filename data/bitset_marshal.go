@@ -0,0 +1,150 @@
+// Serialization for bitsets.
+//
+// Bitsets implement encoding.BinaryMarshaler, encoding.TextMarshaler and
+// json.Marshaler, along with their Unmarshal counterparts:
+//
+// - The binary form is the length as a uvarint followed by the 64-bit words in
+//   little endian order.
+// - The text form is the same index list printed by String(), e.g. [0001,0033].
+//   It does not record the length: when unmarshaling into a bitset that already
+//   has a length, that length is kept, otherwise the length is the largest index
+//   plus one.
+// - The JSON form is an object with the length and the list of set indices, e.g.
+//   {"length":64,"bits":[1,33]}.
+
+package data
+
+import "encoding/binary"
+import "encoding/json"
+import "fmt"
+import "math"
+import "strconv"
+import "strings"
+
+// Largest length of a bitset read from text or JSON, or of a moving average window
+// read from JSON. These forms do not carry data for the whole length, so a short
+// input could otherwise ask for a huge allocation.
+const maxUnmarshalLength = 1 << 24
+
+func (b Bitset) MarshalBinary() ([]byte, error) {
+	result := make([]byte, binary.MaxVarintLen64+8*len(b.binary))
+	n := binary.PutUvarint(result, uint64(b.length))
+	for _, v := range b.binary {
+		binary.LittleEndian.PutUint64(result[n:], v)
+		n += 8
+	}
+	return result[:n], nil
+}
+
+func (b *Bitset) UnmarshalBinary(buf []byte) error {
+	length, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return fmt.Errorf("Cannot read bitset length from %d bytes.", len(buf))
+	}
+	if length == 0 {
+		return fmt.Errorf("Bitset length must be positive, but is 0.")
+	}
+	// Check the length before allocating, so bad input cannot make us allocate
+	// arbitrarily large bitsets.
+	if length > math.MaxInt {
+		return fmt.Errorf("Bitset length %d is too large.", length)
+	}
+	words := (int(length)-1)/64 + 1
+	if len(buf)-n != 8*words {
+		return fmt.Errorf("Bitset of length %d needs %d bytes, but got %d.",
+			length, 8*words, len(buf)-n)
+	}
+	result := NewBitset(int(length))
+	for i := range result.binary {
+		result.binary[i] = binary.LittleEndian.Uint64(buf[n:])
+		n += 8
+	}
+	if rem := result.length % 64; rem != 0 && result.binary[len(result.binary)-1]>>uint64(rem) != 0 {
+		return fmt.Errorf("Bits set past end of bitset of length %d.", length)
+	}
+	*b = *result
+	return nil
+}
+
+func (b Bitset) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *Bitset) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	if !strings.HasPrefix(str, "[") || !strings.HasSuffix(str, "]") {
+		return fmt.Errorf("Bitset must be enclosed in brackets: %q", str)
+	}
+	str = strings.TrimSpace(str[1 : len(str)-1])
+	indices := make([]int, 0)
+	if len(str) > 0 {
+		for _, s := range strings.Split(str, ",") {
+			i, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return fmt.Errorf("Bad index in bitset: %v", err)
+			}
+			indices = append(indices, i)
+		}
+	}
+	return b.resetToIndices(indices)
+}
+
+type bitsetJSON struct {
+	Length int   `json:"length"`
+	Bits   []int `json:"bits"`
+}
+
+func (b Bitset) MarshalJSON() ([]byte, error) {
+	result := bitsetJSON{
+		Length: b.length,
+		Bits:   make([]int, 0, b.NumSetBits()),
+	}
+	b.Foreach(func(i int) {
+		result.Bits = append(result.Bits, i)
+	})
+	return json.Marshal(result)
+}
+
+func (b *Bitset) UnmarshalJSON(buf []byte) error {
+	var in bitsetJSON
+	if err := json.Unmarshal(buf, &in); err != nil {
+		return err
+	}
+	if in.Length <= 0 {
+		return fmt.Errorf("Bitset length must be positive, but is %d.", in.Length)
+	}
+	if in.Length > maxUnmarshalLength {
+		return fmt.Errorf("Bitset length %d is too large (max %d).", in.Length, maxUnmarshalLength)
+	}
+	*b = *NewBitset(in.Length)
+	return b.resetToIndices(in.Bits)
+}
+
+// Sets exactly the given indices. If this bitset has no length yet, it is
+// allocated to fit the largest index.
+func (b *Bitset) resetToIndices(indices []int) error {
+	if b.length == 0 {
+		max := 0
+		for _, i := range indices {
+			if i < 0 || i >= maxUnmarshalLength {
+				return fmt.Errorf("Index %d out of range for bitset (max length %d).",
+					i, maxUnmarshalLength)
+			}
+			if i >= max {
+				max = i + 1
+			}
+		}
+		if max == 0 {
+			return fmt.Errorf("Cannot infer the length of an empty bitset.")
+		}
+		*b = *NewBitset(max)
+	}
+	for _, i := range indices {
+		if i < 0 || i >= b.length {
+			return fmt.Errorf("Index %d out of range for bitset of length %d.",
+				i, b.length)
+		}
+	}
+	b.Reset().Set(indices...)
+	return nil
+}
@@ -0,0 +1,121 @@
+package data
+
+import "encoding/binary"
+import "encoding/json"
+import "math"
+import "testing"
+
+func TestBitsetBinaryRoundTrip(t *testing.T) {
+	for _, n := range []int{1, 64, 100, 2048} {
+		b := NewBitset(n).Set(0, n-1, n/2)
+		buf, err := b.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		other := new(Bitset)
+		if err = other.UnmarshalBinary(buf); err != nil {
+			t.Fatal(err)
+		}
+		if !other.Equals(*b) {
+			t.Errorf("Binary round trip failed. Expected: %v, but got: %v", *b, *other)
+		}
+	}
+}
+
+func TestBitsetUnmarshalBinary_Errors(t *testing.T) {
+	b := NewBitset(100).Set(1, 99)
+	buf, _ := b.MarshalBinary()
+	other := new(Bitset)
+	if err := other.UnmarshalBinary(buf[:len(buf)-1]); err == nil {
+		t.Error("Should fail on truncated input.")
+	}
+	if err := other.UnmarshalBinary([]byte{}); err == nil {
+		t.Error("Should fail on empty input.")
+	}
+	if err := other.UnmarshalBinary(make([]byte, 9)); err == nil {
+		t.Error("Should fail with zero length.")
+	}
+	// Huge lengths must fail before allocating.
+	for _, length := range []uint64{1 << 62, 1 << 40, math.MaxUint64} {
+		huge := make([]byte, binary.MaxVarintLen64+8)
+		n := binary.PutUvarint(huge, length)
+		if err := other.UnmarshalBinary(huge[:n+8]); err == nil {
+			t.Errorf("Should fail with length %d.", length)
+		}
+	}
+	// Set bit 127, past the end of the bitset.
+	buf[len(buf)-1] = 0x80
+	if err := other.UnmarshalBinary(buf); err == nil {
+		t.Error("Should fail with bits past the end.")
+	}
+}
+
+func TestBitsetTextRoundTrip(t *testing.T) {
+	b := NewBitset(2048).Set(1, 33, 2000)
+	text, err := b.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExpectEquals(t, "text", "[0001,0033,2000]", string(text))
+
+	other := NewBitset(2048).Set(5)
+	if err = other.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !other.Equals(*b) {
+		t.Errorf("Text round trip failed. Expected: %v, but got: %v", *b, *other)
+	}
+
+	inferred := new(Bitset)
+	if err = inferred.UnmarshalText([]byte(" [1, 33,2000] ")); err != nil {
+		t.Fatal(err)
+	}
+	ExpectEquals(t, "inferred length", 2001, inferred.Len())
+	ExpectEquals(t, "inferred bits", b.String(), inferred.String())
+}
+
+func TestBitsetUnmarshalText_Errors(t *testing.T) {
+	for _, text := range []string{"1,2", "[1,x]", "[]", "[-1]",
+		"[9223372036854775807]", "[1,16777216]"} {
+		if err := new(Bitset).UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("Should fail to parse %q.", text)
+		}
+	}
+	if err := NewBitset(10).UnmarshalText([]byte("[10]")); err == nil {
+		t.Error("Should fail to parse index past the end.")
+	}
+	empty := NewBitset(10).Set(1)
+	if err := empty.UnmarshalText([]byte("[]")); err != nil || !empty.IsZero() {
+		t.Errorf("Should parse empty bitset with known length: %v, %v", *empty, err)
+	}
+}
+
+func TestBitsetJSON(t *testing.T) {
+	type fixture struct {
+		Name  string
+		Input *Bitset
+	}
+	in := fixture{"a", NewBitset(64).Set(1, 33)}
+	buf, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExpectEquals(t, "json", `{"Name":"a","Input":{"length":64,"bits":[1,33]}}`, string(buf))
+
+	var out fixture
+	if err = json.Unmarshal(buf, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Input.Equals(*in.Input) {
+		t.Errorf("JSON round trip failed. Expected: %v, but got: %v", *in.Input, *out.Input)
+	}
+	if err = json.Unmarshal([]byte(`{"length":0,"bits":[]}`), out.Input); err == nil {
+		t.Error("Should fail with zero length.")
+	}
+	if err = json.Unmarshal([]byte(`{"length":4611686018427387904,"bits":[]}`), out.Input); err == nil {
+		t.Error("Should fail with huge length.")
+	}
+	if err = json.Unmarshal([]byte(`{"length":2,"bits":[2]}`), out.Input); err == nil {
+		t.Error("Should fail with index past the end.")
+	}
+}
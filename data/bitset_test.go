@@ -572,3 +572,265 @@ func BenchmarkDenseCount_All(b *testing.B) {
 		all.DenseCount()
 	}
 }
+
+func TestBitsetXor(t *testing.T) {
+	b := NewBitset(2048).Set(20, 200, 2000)
+	b.Xor(*NewBitset(2048).Set(20, 1000))
+	if !b.Equals(*NewBitset(2048).Set(200, 1000, 2000)) {
+		t.Errorf("Failed b ^ c. Expected [200,1000,2000], but got: %v", *b)
+	}
+	b.Xor(*b.Clone())
+	if !b.IsZero() {
+		t.Errorf("Failed b ^ b == 0. Expected empty, but got: %v", *b)
+	}
+}
+
+func TestBitsetXor_DifferentLength(t *testing.T) {
+	defer func() {
+		err := recover()
+		if err == nil {
+			t.Error("Should have failed, but didn't.")
+		} else if !strings.Contains(fmt.Sprint(err), "Cannot XOR") {
+			t.Errorf("Should panic with the XOR message, but got: %v", err)
+		}
+	}()
+	NewBitset(100).Xor(*NewBitset(101))
+}
+
+func TestBitsetNot(t *testing.T) {
+	b := NewBitset(100).Set(0, 50, 99)
+	b.Not()
+	ExpectEquals(t, "num bits", 97, b.NumSetBits())
+	if b.IsSet(0) || b.IsSet(50) || b.IsSet(99) || !b.IsSet(1) {
+		t.Errorf("Failed ^b: %v", *b)
+	}
+	b.Not()
+	if !b.Equals(*NewBitset(100).Set(0, 50, 99)) {
+		t.Errorf("Failed ^^b == b: %v", *b)
+	}
+	full := NewBitset(128)
+	full.Not()
+	ExpectEquals(t, "num bits", 128, full.NumSetBits())
+}
+
+func TestBitsetIsSubsetOf(t *testing.T) {
+	b := NewBitset(2048).Set(20, 200, 2000)
+	if !NewBitset(2048).IsSubsetOf(*b) {
+		t.Error("Empty set should be a subset of any set.")
+	}
+	if !b.IsSubsetOf(*b) {
+		t.Errorf("Should be a subset of itself: %v", *b)
+	}
+	if !NewBitset(2048).Set(200).IsSubsetOf(*b) {
+		t.Errorf("[200] should be a subset of %v", *b)
+	}
+	if NewBitset(2048).Set(200, 201).IsSubsetOf(*b) {
+		t.Errorf("[200,201] should not be a subset of %v", *b)
+	}
+}
+
+func TestBitsetSimilarity(t *testing.T) {
+	a := NewBitset(2048).Set(1, 2, 3, 4)
+	b := NewBitset(2048).Set(3, 4, 5, 6)
+	ExpectEquals(t, "hamming", 4, a.HammingDistance(*b))
+	ExpectEquals(t, "hamming to self", 0, a.HammingDistance(*a))
+	ExpectEquals(t, "jaccard", 2.0/6.0, a.Jaccard(*b))
+	ExpectEquals(t, "jaccard to self", 1.0, a.Jaccard(*a))
+	ExpectEquals(t, "jaccard of empty", 1.0, NewBitset(10).Jaccard(*NewBitset(10)))
+	ExpectEquals(t, "cosine", 0.5, a.Cosine(*b))
+	ExpectEquals(t, "cosine to self", 1.0, a.Cosine(*a))
+	ExpectEquals(t, "cosine to empty", 0.0, a.Cosine(*NewBitset(2048)))
+}
+
+func TestBitsetIndices(t *testing.T) {
+	bits := []int{1, 33, 63, 64, 2000}
+	indices := NewBitset(2048).Set(bits...).Indices()
+	ExpectEquals(t, "len", len(bits), len(indices))
+	for i, v := range bits {
+		ExpectEquals(t, "index", v, indices[i])
+	}
+	ExpectEquals(t, "empty", 0, len(NewBitset(10).Indices()))
+}
+
+func TestBitsetSubsample(t *testing.T) {
+	rng := rand.New(rand.NewSource(1979))
+	b := NewBitset(2048).Set(1, 33, 63, 64, 2000, 2047)
+	s := b.Subsample(3, rng)
+	ExpectEquals(t, "num bits", 3, s.NumSetBits())
+	if !s.IsSubsetOf(*b) {
+		t.Errorf("Subsample %v should be a subset of %v", *s, *b)
+	}
+	if !b.Subsample(10, rng).Equals(*b) {
+		t.Errorf("Subsample larger than the bitset should copy it: %v", *b)
+	}
+	if !b.Subsample(0, rng).IsZero() {
+		t.Error("Empty subsample should be zero.")
+	}
+
+	// Every bit should be picked sometimes.
+	counts := NewBitset(2048)
+	for i := 0; i < 100; i++ {
+		counts.Or(*b.Subsample(1, rng))
+	}
+	if !counts.Equals(*b) {
+		t.Errorf("Subsample is not uniform: %v", *counts)
+	}
+}
+
+func BenchmarkHammingDistance(b *testing.B) {
+	rand.Seed(1979)
+	x, y := NewBitset(2048), NewBitset(2048)
+	for i := 0; i < 40; i++ {
+		x.Set(rand.Intn(2048))
+		y.Set(rand.Intn(2048))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.HammingDistance(*y)
+	}
+}
+
+func BenchmarkJaccard(b *testing.B) {
+	rand.Seed(1979)
+	x, y := NewBitset(2048), NewBitset(2048)
+	for i := 0; i < 40; i++ {
+		x.Set(rand.Intn(2048))
+		y.Set(rand.Intn(2048))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Jaccard(*y)
+	}
+}
+
+func BenchmarkSubsample(b *testing.B) {
+	rng := rand.New(rand.NewSource(1979))
+	x := NewBitset(2048)
+	for i := 0; i < 40; i++ {
+		x.Set(rng.Intn(2048))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Subsample(10, rng)
+	}
+}
+
+func BenchmarkIndices(b *testing.B) {
+	rand.Seed(1979)
+	x := NewBitset(2048)
+	for i := 0; i < 40; i++ {
+		x.Set(rand.Intn(2048))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		x.Indices()
+	}
+}
+
+func TestBitsetSlice(t *testing.T) {
+	b := NewBitset(2048).Set(0, 63, 64, 100, 1000, 2047)
+	ExpectEquals(t, "slice", "[0000,0001,0037]", b.Slice(63, 101).String())
+	ExpectEquals(t, "slice length", 38, b.Slice(63, 101).Len())
+	ExpectEquals(t, "aligned slice", "[0000,0036]", b.Slice(64, 128).String())
+	ExpectEquals(t, "end slice", "[0047]", b.Slice(2000, 2048).String())
+	if !b.Slice(0, 2048).Equals(*b) {
+		t.Errorf("Full slice should be equal: %v", *b.Slice(0, 2048))
+	}
+	if !b.Slice(101, 101).IsZero() {
+		t.Error("Empty slice should be zero.")
+	}
+	// Bits past the end of the slice must be cleared.
+	ExpectEquals(t, "short slice", "[0000]", b.Slice(63, 64).String())
+}
+
+func TestBitsetSlice_OutOfRange(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("Should have failed, but didn't.")
+		}
+	}()
+	NewBitset(100).Slice(50, 101)
+}
+
+func TestBitsetOverlap_DifferentLength(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("Should have failed, but didn't.")
+		}
+	}()
+	shrunk := NewBitset(200).Set(150).Resize(64)
+	NewBitset(200).Set(150).Overlap(*shrunk)
+}
+
+func TestBitsetResize(t *testing.T) {
+	b := NewBitset(100).Set(1, 50, 99)
+	b.Resize(200).Set(150)
+	ExpectEquals(t, "grown", "[0001,0050,0099,0150]", b.String())
+	ExpectEquals(t, "grown length", 200, b.Len())
+	b.Resize(60)
+	ExpectEquals(t, "shrunk", "[0001,0050]", b.String())
+	for i, v := range b.binary[:cap(b.binary)] {
+		if v != 0 && i >= len(b.binary) {
+			t.Errorf("Dropped word %d should be clear, but is %x.", i, v)
+		}
+	}
+	// Growing again must not bring the dropped bits back.
+	b.Resize(200)
+	ExpectEquals(t, "regrown", "[0001,0050]", b.String())
+
+	b.Resize(0)
+	ExpectEquals(t, "empty length", 0, b.Len())
+	ExpectEquals(t, "empty bits", 0, b.NumSetBits())
+	b.Resize(64)
+	ExpectEquals(t, "regrown from empty", "[]", b.String())
+}
+
+func TestBitsetResize_Negative(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("Should have failed, but didn't.")
+		}
+	}()
+	NewBitset(10).Resize(-1)
+}
+
+func TestConcat(t *testing.T) {
+	a := NewBitset(10).Set(0, 9)
+	b := NewBitset(100).Set(0, 99)
+	c := NewBitset(3).Set(1)
+	result := Concat(*a, *b, *c)
+	ExpectEquals(t, "length", 113, result.Len())
+	ExpectEquals(t, "concat", "[0000,0009,0010,0109,0111]", result.String())
+	ExpectEquals(t, "empty concat", 0, Concat().Len())
+}
+
+func WordLoopBenchmarkTemplate(b *testing.B, op func(x, y *Bitset)) {
+	rand.Seed(1979)
+	x, y := NewBitset(2048), NewBitset(2048)
+	for i := 0; i < 40; i++ {
+		x.Set(rand.Intn(2048))
+		y.Set(rand.Intn(2048))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		op(x, y)
+	}
+}
+
+func BenchmarkOverlap(b *testing.B) {
+	WordLoopBenchmarkTemplate(b, func(x, y *Bitset) {
+		x.Overlap(*y)
+	})
+}
+
+func BenchmarkOr(b *testing.B) {
+	WordLoopBenchmarkTemplate(b, func(x, y *Bitset) {
+		x.Or(*y)
+	})
+}
+
+func BenchmarkAnd(b *testing.B) {
+	WordLoopBenchmarkTemplate(b, func(x, y *Bitset) {
+		x.And(*y)
+	})
+}
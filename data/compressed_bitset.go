@@ -0,0 +1,506 @@
+// Compressed bitset implementation for HTM.
+//
+// Large regions (hundreds of thousands of cells) keep several state bitsets that
+// are mostly empty. A CompressedBitset splits the index space into chunks of 2^16
+// bits, in the style of Roaring bitmaps: chunks without bits take no memory,
+// sparse chunks are stored as sorted arrays of 16-bit offsets and dense chunks
+// as plain 1024-word bitmaps.
+//
+// The compressed form pays off for states with around one cell per active column
+// (learning and predictive states), where it is an order of magnitude faster than
+// the dense form. Bursting states are dense enough that Bitset is still faster.
+
+package data
+
+import "fmt"
+import "math/bits"
+import "sort"
+import "strings"
+
+// Read-only operations shared by all the bitset representations.
+type BitsetReader interface {
+	// The number of valid bits.
+	Len() int
+	IsSet(index int) bool
+	NumSetBits() int
+	IsZero() bool
+	// Calls f for each set index, in ascending order.
+	Foreach(f func(int))
+}
+
+var _ BitsetReader = Bitset{}
+var _ BitsetReader = SparseBitset{}
+var _ BitsetReader = CompressedBitset{}
+
+const (
+	chunkBits = 16
+	chunkSize = 1 << chunkBits
+	// Chunks with more bits than this are stored as bitmaps.
+	maxArrayCardinality = 4096
+)
+
+// A chunk of 2^16 bits. If bitmap is nil, the set offsets are stored in array.
+type chunk struct {
+	array       []uint16
+	bitmap      []uint64
+	cardinality int
+}
+
+func (c chunk) isSet(offset uint16) bool {
+	if c.bitmap != nil {
+		return c.bitmap[offset/64]&(1<<uint64(offset%64)) != 0
+	}
+	pos := c.search(offset)
+	return pos < len(c.array) && c.array[pos] == offset
+}
+
+func (c chunk) search(offset uint16) int {
+	return sort.Search(len(c.array), func(i int) bool {
+		return c.array[i] >= offset
+	})
+}
+
+func (c *chunk) set(offset uint16) {
+	if c.bitmap != nil {
+		mask := uint64(1) << uint64(offset%64)
+		if c.bitmap[offset/64]&mask == 0 {
+			c.bitmap[offset/64] |= mask
+			c.cardinality++
+		}
+		return
+	}
+	pos := c.search(offset)
+	if pos < len(c.array) && c.array[pos] == offset {
+		return
+	}
+	c.array = append(c.array, 0)
+	copy(c.array[pos+1:], c.array[pos:])
+	c.array[pos] = offset
+	c.cardinality++
+	if c.cardinality > maxArrayCardinality {
+		c.toBitmap()
+	}
+}
+
+func (c *chunk) unset(offset uint16) {
+	if c.bitmap != nil {
+		mask := uint64(1) << uint64(offset%64)
+		if c.bitmap[offset/64]&mask != 0 {
+			c.bitmap[offset/64] &= ^mask
+			c.cardinality--
+		}
+		if c.cardinality <= maxArrayCardinality {
+			c.toArray()
+		}
+		return
+	}
+	pos := c.search(offset)
+	if pos < len(c.array) && c.array[pos] == offset {
+		c.array = append(c.array[:pos], c.array[pos+1:]...)
+		c.cardinality--
+	}
+}
+
+func (c *chunk) toBitmap() {
+	c.bitmap = make([]uint64, chunkSize/64)
+	for _, v := range c.array {
+		c.bitmap[v/64] |= 1 << uint64(v%64)
+	}
+	c.array = nil
+}
+
+func (c *chunk) toArray() {
+	c.array = make([]uint16, 0, c.cardinality)
+	c.foreach(0, func(i int) {
+		c.array = append(c.array, uint16(i))
+	})
+	c.bitmap = nil
+}
+
+func (c chunk) foreach(base int, f func(int)) {
+	if c.bitmap == nil {
+		for _, v := range c.array {
+			f(base + int(v))
+		}
+		return
+	}
+	for pos, el := range c.bitmap {
+		for el > 0 {
+			f(base + pos*64 + deBrujin64Table[((el&-el)*deBrujin64)>>58])
+			el &= el - 1
+		}
+	}
+}
+
+func (c chunk) overlap(other chunk) (count int) {
+	switch {
+	case c.bitmap != nil && other.bitmap != nil:
+		for i, el := range c.bitmap {
+			count += bits.OnesCount64(el & other.bitmap[i])
+		}
+	case c.bitmap != nil:
+		return other.overlap(c)
+	case other.bitmap != nil:
+		for _, v := range c.array {
+			if other.isSet(v) {
+				count++
+			}
+		}
+	default:
+		i, j := 0, 0
+		for i < len(c.array) && j < len(other.array) {
+			switch {
+			case c.array[i] < other.array[j]:
+				i++
+			case c.array[i] > other.array[j]:
+				j++
+			default:
+				count++
+				i++
+				j++
+			}
+		}
+	}
+	return
+}
+
+func (c *chunk) or(other chunk) {
+	if c.bitmap == nil && other.bitmap == nil &&
+		c.cardinality+other.cardinality <= maxArrayCardinality {
+		merged := make([]uint16, 0, c.cardinality+other.cardinality)
+		i, j := 0, 0
+		for i < len(c.array) && j < len(other.array) {
+			switch {
+			case c.array[i] < other.array[j]:
+				merged = append(merged, c.array[i])
+				i++
+			case c.array[i] > other.array[j]:
+				merged = append(merged, other.array[j])
+				j++
+			default:
+				merged = append(merged, c.array[i])
+				i++
+				j++
+			}
+		}
+		merged = append(merged, c.array[i:]...)
+		merged = append(merged, other.array[j:]...)
+		c.array = merged
+		c.cardinality = len(merged)
+		return
+	}
+	if c.bitmap == nil {
+		c.toBitmap()
+	}
+	if other.bitmap == nil {
+		for _, v := range other.array {
+			c.bitmap[v/64] |= 1 << uint64(v%64)
+		}
+	} else {
+		for i, el := range other.bitmap {
+			c.bitmap[i] |= el
+		}
+	}
+	c.recount()
+}
+
+func (c *chunk) and(other chunk) {
+	if c.bitmap != nil && other.bitmap != nil {
+		for i, el := range other.bitmap {
+			c.bitmap[i] &= el
+		}
+		c.recount()
+		return
+	}
+	kept := make([]uint16, 0, c.cardinality)
+	if c.bitmap == nil && other.bitmap == nil {
+		i, j := 0, 0
+		for i < len(c.array) && j < len(other.array) {
+			switch {
+			case c.array[i] < other.array[j]:
+				i++
+			case c.array[i] > other.array[j]:
+				j++
+			default:
+				kept = append(kept, c.array[i])
+				i++
+				j++
+			}
+		}
+	} else {
+		c.foreach(0, func(i int) {
+			if other.isSet(uint16(i)) {
+				kept = append(kept, uint16(i))
+			}
+		})
+	}
+	c.array = kept
+	c.bitmap = nil
+	c.cardinality = len(kept)
+}
+
+func (c *chunk) recount() {
+	c.cardinality = 0
+	for _, el := range c.bitmap {
+		c.cardinality += bits.OnesCount64(el)
+	}
+	if c.cardinality <= maxArrayCardinality {
+		c.toArray()
+	}
+}
+
+func (c chunk) clone() chunk {
+	result := chunk{cardinality: c.cardinality}
+	if c.bitmap != nil {
+		result.bitmap = make([]uint64, len(c.bitmap))
+		copy(result.bitmap, c.bitmap)
+	} else {
+		result.array = make([]uint16, len(c.array))
+		copy(result.array, c.array)
+	}
+	return result
+}
+
+// A compressed bitset. Only the chunks that have bits set take memory.
+type CompressedBitset struct {
+	// The high 16 bits of the indices in each chunk, in ascending order.
+	keys   []int
+	chunks []chunk
+	// The valid length of this bitset, in bits.
+	length int
+}
+
+// Creates a new, empty compressed bitset of the given length.
+func NewCompressedBitset(length int) *CompressedBitset {
+	return &CompressedBitset{
+		keys:   make([]int, 0),
+		chunks: make([]chunk, 0),
+		length: length,
+	}
+}
+
+// Creates a new compressed bitset with the same bits as the given bitset.
+func CompressedBitsetFromBits(bits BitsetReader) *CompressedBitset {
+	result := NewCompressedBitset(bits.Len())
+	bits.Foreach(func(i int) {
+		result.Set(i)
+	})
+	return result
+}
+
+func (c CompressedBitset) find(key int) (int, bool) {
+	pos := sort.SearchInts(c.keys, key)
+	return pos, pos < len(c.keys) && c.keys[pos] == key
+}
+
+func (c CompressedBitset) Len() int {
+	return c.length
+}
+
+func (c CompressedBitset) IsSet(index int) bool {
+	if index < 0 || index >= c.length {
+		return false
+	}
+	if pos, ok := c.find(index >> chunkBits); ok {
+		return c.chunks[pos].isSet(uint16(index))
+	}
+	return false
+}
+
+func (c CompressedBitset) NumSetBits() (count int) {
+	for _, ch := range c.chunks {
+		count += ch.cardinality
+	}
+	return
+}
+
+func (c CompressedBitset) IsZero() bool {
+	return len(c.chunks) == 0
+}
+
+func (c CompressedBitset) Foreach(f func(int)) {
+	for i, ch := range c.chunks {
+		ch.foreach(c.keys[i]<<chunkBits, f)
+	}
+}
+
+func (c *CompressedBitset) Reset() *CompressedBitset {
+	c.keys = c.keys[0:0]
+	c.chunks = c.chunks[0:0]
+	return c
+}
+
+func (c *CompressedBitset) Set(indices ...int) *CompressedBitset {
+	for _, v := range indices {
+		if v >= c.length {
+			panic(fmt.Errorf(
+				"Attempt to write past end of bitset (%d > %d)", v, c.length))
+		}
+		if v < 0 {
+			panic(fmt.Errorf(
+				"Attempt to write before start of bitset (%d < %d)", v, 0))
+		}
+		key := v >> chunkBits
+		pos, ok := c.find(key)
+		if !ok {
+			c.keys = append(c.keys, 0)
+			copy(c.keys[pos+1:], c.keys[pos:])
+			c.keys[pos] = key
+			c.chunks = append(c.chunks, chunk{})
+			copy(c.chunks[pos+1:], c.chunks[pos:])
+			c.chunks[pos] = chunk{}
+		}
+		c.chunks[pos].set(uint16(v))
+	}
+	return c
+}
+
+func (c *CompressedBitset) Unset(indices ...int) *CompressedBitset {
+	for _, v := range indices {
+		if v < 0 || v >= c.length {
+			continue
+		}
+		if pos, ok := c.find(v >> chunkBits); ok {
+			c.chunks[pos].unset(uint16(v))
+			c.removeIfEmpty(pos)
+		}
+	}
+	return c
+}
+
+func (c *CompressedBitset) removeIfEmpty(pos int) {
+	if c.chunks[pos].cardinality == 0 {
+		c.keys = append(c.keys[:pos], c.keys[pos+1:]...)
+		c.chunks = append(c.chunks[:pos], c.chunks[pos+1:]...)
+	}
+}
+
+// Counts the bits that are set in both compressed bitsets.
+func (c CompressedBitset) Overlap(other CompressedBitset) (count int) {
+	i, j := 0, 0
+	for i < len(c.keys) && j < len(other.keys) {
+		switch {
+		case c.keys[i] < other.keys[j]:
+			i++
+		case c.keys[i] > other.keys[j]:
+			j++
+		default:
+			count += c.chunks[i].overlap(other.chunks[j])
+			i++
+			j++
+		}
+	}
+	return
+}
+
+// Sets in this bitset all the bits that are set in the other (union).
+func (c *CompressedBitset) Or(other CompressedBitset) {
+	if c.length != other.length {
+		panic(fmt.Errorf(
+			"Cannot OR bitsets of different length (%d != %d)", c.length, other.length))
+	}
+	keys := make([]int, 0, len(c.keys)+len(other.keys))
+	chunks := make([]chunk, 0, len(c.keys)+len(other.keys))
+	i, j := 0, 0
+	for i < len(c.keys) && j < len(other.keys) {
+		switch {
+		case c.keys[i] < other.keys[j]:
+			keys = append(keys, c.keys[i])
+			chunks = append(chunks, c.chunks[i])
+			i++
+		case c.keys[i] > other.keys[j]:
+			keys = append(keys, other.keys[j])
+			chunks = append(chunks, other.chunks[j].clone())
+			j++
+		default:
+			c.chunks[i].or(other.chunks[j])
+			keys = append(keys, c.keys[i])
+			chunks = append(chunks, c.chunks[i])
+			i++
+			j++
+		}
+	}
+	keys = append(keys, c.keys[i:]...)
+	chunks = append(chunks, c.chunks[i:]...)
+	for ; j < len(other.keys); j++ {
+		keys = append(keys, other.keys[j])
+		chunks = append(chunks, other.chunks[j].clone())
+	}
+	c.keys = keys
+	c.chunks = chunks
+}
+
+// Clears in this bitset all the bits that are not set in the other
+// (intersection).
+func (c *CompressedBitset) And(other CompressedBitset) {
+	if c.length != other.length {
+		panic(fmt.Errorf(
+			"Cannot AND bitsets of different length (%d != %d)", c.length, other.length))
+	}
+	keys := c.keys[0:0]
+	chunks := c.chunks[0:0]
+	i, j := 0, 0
+	for i < len(c.keys) && j < len(other.keys) {
+		switch {
+		case c.keys[i] < other.keys[j]:
+			i++
+		case c.keys[i] > other.keys[j]:
+			j++
+		default:
+			ch := c.chunks[i]
+			ch.and(other.chunks[j])
+			if ch.cardinality > 0 {
+				keys = append(keys, c.keys[i])
+				chunks = append(chunks, ch)
+			}
+			i++
+			j++
+		}
+	}
+	c.keys = keys
+	c.chunks = chunks
+}
+
+// Converts this compressed bitset into a new dense bitset.
+func (c CompressedBitset) ToBitset() *Bitset {
+	result := NewBitset(c.length)
+	c.Foreach(func(i int) {
+		result.binary[i/64] |= 1 << uint64(i%64)
+	})
+	return result
+}
+
+func (c CompressedBitset) Equals(other CompressedBitset) bool {
+	if c.length != other.length || len(c.keys) != len(other.keys) {
+		return false
+	}
+	for i, key := range c.keys {
+		if key != other.keys[i] ||
+			c.chunks[i].cardinality != other.chunks[i].cardinality ||
+			c.chunks[i].overlap(other.chunks[i]) != c.chunks[i].cardinality {
+			return false
+		}
+	}
+	return true
+}
+
+func (c CompressedBitset) Clone() *CompressedBitset {
+	result := &CompressedBitset{
+		keys:   make([]int, len(c.keys)),
+		chunks: make([]chunk, len(c.chunks)),
+		length: c.length,
+	}
+	copy(result.keys, c.keys)
+	for i, ch := range c.chunks {
+		result.chunks[i] = ch.clone()
+	}
+	return result
+}
+
+func (c CompressedBitset) String() string {
+	s := make([]string, 0, c.NumSetBits())
+	c.Foreach(func(i int) {
+		s = append(s, fmt.Sprintf("%04d", i))
+	})
+	return "[" + strings.Join(s, ",") + "]"
+}
@@ -0,0 +1,199 @@
+package data
+
+import "math/rand"
+import "testing"
+
+func randomBitset(n, l int) *Bitset {
+	b := NewBitset(n)
+	for i := 0; i < l; i++ {
+		b.Set(rand.Intn(n))
+	}
+	return b
+}
+
+func TestCompressedBitsetSetAndUnset(t *testing.T) {
+	c := NewCompressedBitset(200000)
+	c.Set(1, 65535, 65536, 199999, 1)
+	ExpectEquals(t, "num bits", 4, c.NumSetBits())
+	ExpectEquals(t, "chunks", 3, len(c.chunks))
+	ExpectEquals(t, "string", "[0001,65535,65536,199999]", c.String())
+	if !c.IsSet(65536) || c.IsSet(65537) || c.IsSet(-1) || c.IsSet(200000) {
+		t.Errorf("Bad IsSet: %v", *c)
+	}
+	c.Unset(65536, 3)
+	ExpectEquals(t, "chunks", 2, len(c.chunks))
+	ExpectEquals(t, "string", "[0001,65535,199999]", c.String())
+	c.Reset()
+	if !c.IsZero() {
+		t.Errorf("Should be zero: %v", *c)
+	}
+}
+
+func TestCompressedBitsetSet_AfterLength(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("Should have failed, but didn't.")
+		}
+	}()
+	NewCompressedBitset(10).Set(10)
+}
+
+func TestCompressedBitsetDenseChunk(t *testing.T) {
+	c := NewCompressedBitset(chunkSize)
+	for i := 0; i <= maxArrayCardinality; i++ {
+		c.Set(i * 2)
+	}
+	if c.chunks[0].bitmap == nil {
+		t.Fatalf("Chunk should have been converted to a bitmap.")
+	}
+	ExpectEquals(t, "num bits", maxArrayCardinality+1, c.NumSetBits())
+	c.Unset(0)
+	if c.chunks[0].bitmap != nil {
+		t.Errorf("Chunk should have been converted back to an array.")
+	}
+	ExpectEquals(t, "num bits", maxArrayCardinality, c.NumSetBits())
+	if !c.IsSet(2) || c.IsSet(0) || c.IsSet(3) {
+		t.Errorf("Bad IsSet after conversion.")
+	}
+}
+
+func TestCompressedBitsetConversion(t *testing.T) {
+	rand.Seed(1979)
+	b := randomBitset(300000, 6000)
+	c := CompressedBitsetFromBits(*b)
+	if !c.ToBitset().Equals(*b) {
+		t.Errorf("Round trip failed.")
+	}
+	ExpectEquals(t, "num bits", b.NumSetBits(), c.NumSetBits())
+	if !c.Equals(*c.Clone()) {
+		t.Errorf("Should be equal to its clone.")
+	}
+}
+
+func TestCompressedBitsetOperations(t *testing.T) {
+	rand.Seed(1979)
+	// The third case makes dense chunks on both sides.
+	for _, l := range []int{100, 6000, 100000} {
+		a := randomBitset(300000, l)
+		b := randomBitset(300000, l/2)
+		ca := CompressedBitsetFromBits(*a)
+		cb := CompressedBitsetFromBits(*b)
+		ExpectEquals(t, "overlap", a.Overlap(*b), ca.Overlap(*cb))
+
+		union := a.Clone()
+		union.Or(*b)
+		cu := ca.Clone()
+		cu.Or(*cb)
+		if !cu.ToBitset().Equals(*union) {
+			t.Errorf("Union failed for %d bits.", l)
+		}
+		ExpectEquals(t, "union bits", union.NumSetBits(), cu.NumSetBits())
+
+		intersection := a.Clone()
+		intersection.And(*b)
+		ci := ca.Clone()
+		ci.And(*cb)
+		if !ci.ToBitset().Equals(*intersection) {
+			t.Errorf("Intersection failed for %d bits.", l)
+		}
+		ExpectEquals(t, "intersection bits", intersection.NumSetBits(), ci.NumSetBits())
+	}
+}
+
+func CompressedBenchmarkTemplate(b *testing.B, n, l int, op func(x, y *CompressedBitset)) {
+	rand.Seed(1979)
+	x := CompressedBitsetFromBits(*randomBitset(n, l))
+	y := CompressedBitsetFromBits(*randomBitset(n, l))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		op(x, y)
+	}
+}
+
+func DenseBenchmarkTemplate(b *testing.B, n, l int, op func(x, y *Bitset)) {
+	rand.Seed(1979)
+	x := randomBitset(n, l)
+	y := randomBitset(n, l)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		op(x, y)
+	}
+}
+
+// A region of 16384 columns by 32 cells, with 2% active columns. Bursting
+// activates every cell in the column, learning only one.
+const benchmarkCells = 16384 * 32
+const benchmarkActive = benchmarkCells / 50
+const benchmarkLearning = benchmarkActive / 32
+
+func BenchmarkCompressedOverlap_Active(b *testing.B) {
+	CompressedBenchmarkTemplate(b, benchmarkCells, benchmarkActive, func(x, y *CompressedBitset) {
+		x.Overlap(*y)
+	})
+}
+
+func BenchmarkCompressedOverlap_Learning(b *testing.B) {
+	CompressedBenchmarkTemplate(b, benchmarkCells, benchmarkLearning, func(x, y *CompressedBitset) {
+		x.Overlap(*y)
+	})
+}
+
+func BenchmarkDenseOverlap_Active(b *testing.B) {
+	DenseBenchmarkTemplate(b, benchmarkCells, benchmarkActive, func(x, y *Bitset) {
+		x.Overlap(*y)
+	})
+}
+
+func BenchmarkDenseOverlap_Learning(b *testing.B) {
+	DenseBenchmarkTemplate(b, benchmarkCells, benchmarkLearning, func(x, y *Bitset) {
+		x.Overlap(*y)
+	})
+}
+
+func BenchmarkCompressedUnion_Active(b *testing.B) {
+	CompressedBenchmarkTemplate(b, benchmarkCells, benchmarkActive, func(x, y *CompressedBitset) {
+		x.Clone().Or(*y)
+	})
+}
+
+func BenchmarkCompressedUnion_Learning(b *testing.B) {
+	CompressedBenchmarkTemplate(b, benchmarkCells, benchmarkLearning, func(x, y *CompressedBitset) {
+		x.Clone().Or(*y)
+	})
+}
+
+func BenchmarkDenseUnion_Active(b *testing.B) {
+	DenseBenchmarkTemplate(b, benchmarkCells, benchmarkActive, func(x, y *Bitset) {
+		x.Clone().Or(*y)
+	})
+}
+
+func BenchmarkDenseUnion_Learning(b *testing.B) {
+	DenseBenchmarkTemplate(b, benchmarkCells, benchmarkLearning, func(x, y *Bitset) {
+		x.Clone().Or(*y)
+	})
+}
+
+func BenchmarkCompressedIntersection_Active(b *testing.B) {
+	CompressedBenchmarkTemplate(b, benchmarkCells, benchmarkActive, func(x, y *CompressedBitset) {
+		x.Clone().And(*y)
+	})
+}
+
+func BenchmarkCompressedIntersection_Learning(b *testing.B) {
+	CompressedBenchmarkTemplate(b, benchmarkCells, benchmarkLearning, func(x, y *CompressedBitset) {
+		x.Clone().And(*y)
+	})
+}
+
+func BenchmarkDenseIntersection_Active(b *testing.B) {
+	DenseBenchmarkTemplate(b, benchmarkCells, benchmarkActive, func(x, y *Bitset) {
+		x.Clone().And(*y)
+	})
+}
+
+func BenchmarkDenseIntersection_Learning(b *testing.B) {
+	DenseBenchmarkTemplate(b, benchmarkCells, benchmarkLearning, func(x, y *Bitset) {
+		x.Clone().And(*y)
+	})
+}
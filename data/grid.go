@@ -0,0 +1,88 @@
+package data
+
+import "fmt"
+import "io"
+
+// A bitset laid out in rows, for inputs with a 2-d shape such as images. Bit
+// (row, col) is at index row*Cols + col.
+type Grid struct {
+	Rows, Cols int
+	Bits       *Bitset
+}
+
+// Creates a new grid with all bits unset.
+func NewGrid(rows, cols int) *Grid {
+	return &Grid{rows, cols, NewBitset(rows * cols)}
+}
+
+// Wraps bits as a grid of the given shape, without copying them.
+func GridFromBitset(bits *Bitset, rows, cols int) (*Grid, error) {
+	if rows*cols != bits.Len() {
+		return nil, fmt.Errorf("Cannot lay out %d bits as %dx%d.", bits.Len(), rows, cols)
+	}
+	return &Grid{rows, cols, bits}, nil
+}
+
+func (g Grid) index(row, col int) int {
+	if row < 0 || row >= g.Rows || col < 0 || col >= g.Cols {
+		panic(fmt.Errorf("(%d, %d) is out of bounds for %dx%d grid.", row, col, g.Rows, g.Cols))
+	}
+	return row*g.Cols + col
+}
+
+func (g Grid) IsSet(row, col int) bool {
+	return g.Bits.IsSet(g.index(row, col))
+}
+
+func (g *Grid) Set(row, col int) *Grid {
+	g.Bits.Set(g.index(row, col))
+	return g
+}
+
+func (g *Grid) Unset(row, col int) *Grid {
+	g.Bits.Unset(g.index(row, col))
+	return g
+}
+
+// Returns a copy of the rows x cols rectangle that starts at (row, col).
+func (g Grid) Tile(row, col, rows, cols int) *Grid {
+	if rows <= 0 || cols <= 0 || row < 0 || col < 0 || row+rows > g.Rows || col+cols > g.Cols {
+		panic(fmt.Errorf("Tile %dx%d at (%d, %d) is out of bounds for %dx%d grid.",
+			rows, cols, row, col, g.Rows, g.Cols))
+	}
+	result := NewGrid(rows, cols)
+	for r := 0; r < rows; r++ {
+		start := (row+r)*g.Cols + col
+		result.Bits.SetFromBitsetAt(*g.Bits.Slice(start, start+cols), r*cols)
+	}
+	return result
+}
+
+// Splits the grid into tiles of rows x cols, in row-major order, so that each tile
+// can feed its own region. The grid must be a multiple of the tile size.
+func (g Grid) Tiles(rows, cols int) ([]*Grid, error) {
+	if rows <= 0 || cols <= 0 || g.Rows%rows != 0 || g.Cols%cols != 0 {
+		return nil, fmt.Errorf("Cannot split %dx%d grid into %dx%d tiles.",
+			g.Rows, g.Cols, rows, cols)
+	}
+	result := make([]*Grid, 0, (g.Rows/rows)*(g.Cols/cols))
+	for r := 0; r < g.Rows; r += rows {
+		for c := 0; c < g.Cols; c += cols {
+			result = append(result, g.Tile(r, c, rows, cols))
+		}
+	}
+	return result, nil
+}
+
+func (g Grid) Equals(other Grid) bool {
+	return g.Rows == other.Rows && g.Cols == other.Cols && g.Bits.Equals(*other.Bits)
+}
+
+func (g Grid) String() string {
+	return fmt.Sprintf("%dx%d%v", g.Rows, g.Cols, *g.Bits)
+}
+
+// Prints one row per line, see Bitset.Print().
+func (g Grid) Print(writer io.Writer) error {
+	return g.Bits.Print(g.Cols, writer)
+}
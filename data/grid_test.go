@@ -0,0 +1,39 @@
+package data
+
+import "bytes"
+import "testing"
+
+func TestGrid(t *testing.T) {
+	g := NewGrid(4, 6)
+	g.Set(0, 0).Set(1, 2).Set(3, 5)
+	ExpectEquals(t, "bits", 24, g.Bits.Len())
+	if !g.IsSet(1, 2) || !g.Bits.IsSet(8) || g.IsSet(2, 1) {
+		t.Errorf("Bad grid: %v", g)
+	}
+	var buf bytes.Buffer
+	g.Print(&buf)
+	ExpectEquals(t, "print", "x-----\n--x---\n------\n-----x\n", buf.String())
+
+	tile := g.Tile(1, 2, 3, 4)
+	ExpectEquals(t, "tile", "3x4[0000,0011]", tile.String())
+
+	tiles, err := g.Tiles(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExpectEquals(t, "tiles", 4, len(tiles))
+	expected := []string{"2x3[0000,0005]", "2x3[]", "2x3[]", "2x3[0005]"}
+	for i, tile := range tiles {
+		ExpectEquals(t, "tile", expected[i], tile.String())
+	}
+	tiles[0].Set(0, 1)
+	if !tiles[0].IsSet(0, 1) || g.IsSet(0, 1) {
+		t.Errorf("Tiles should be copies: %v", g)
+	}
+	if _, err := g.Tiles(3, 3); err == nil {
+		t.Error("Should fail with tiles that do not divide the grid.")
+	}
+	if _, err := GridFromBitset(NewBitset(10), 3, 3); err == nil {
+		t.Error("Should fail with the wrong shape.")
+	}
+}
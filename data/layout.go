@@ -0,0 +1,123 @@
+package data
+
+import "fmt"
+
+// Remembers where each named field starts in a bitset made of several bitsets
+// side by side, so the pieces can be joined and split back apart.
+type Layout struct {
+	names   []string
+	offsets []int
+	lengths []int
+	index   map[string]int
+	length  int
+}
+
+// Creates a new, empty layout.
+func NewLayout() *Layout {
+	return &Layout{
+		names:   make([]string, 0),
+		offsets: make([]int, 0),
+		lengths: make([]int, 0),
+		index:   make(map[string]int),
+	}
+}
+
+// Appends a field of the given length to the end of this layout. Field names must
+// be unique.
+func (l *Layout) Add(name string, length int) error {
+	if _, ok := l.index[name]; ok {
+		return fmt.Errorf("Duplicate field \"%s\" in layout.", name)
+	}
+	if length <= 0 {
+		return fmt.Errorf("Field \"%s\" must have a positive length, but has %d.",
+			name, length)
+	}
+	l.index[name] = len(l.names)
+	l.names = append(l.names, name)
+	l.offsets = append(l.offsets, l.length)
+	l.lengths = append(l.lengths, length)
+	l.length += length
+	return nil
+}
+
+// The total length of the layout, in bits.
+func (l Layout) Len() int {
+	return l.length
+}
+
+// The number of fields in the layout.
+func (l Layout) NumFields() int {
+	return len(l.names)
+}
+
+// The names of the fields, in order. The returned slice must not be modified.
+func (l Layout) Names() []string {
+	return l.names
+}
+
+// Returns the offset and length of the named field, with the ok idiom.
+func (l Layout) Field(name string) (offset, length int, ok bool) {
+	var i int
+	if i, ok = l.index[name]; ok {
+		offset, length = l.offsets[i], l.lengths[i]
+	}
+	return
+}
+
+// Returns the name of the field that contains the given bit index, or false if the
+// index is outside the layout.
+func (l Layout) FieldAt(index int) (name string, ok bool) {
+	for i, offset := range l.offsets {
+		if index >= offset && index < offset+l.lengths[i] {
+			return l.names[i], true
+		}
+	}
+	return
+}
+
+// Creates a new bitset with the given bitsets side by side. There must be one
+// bitset per field, in order, each with the length of its field.
+func (l Layout) Join(fields ...Bitset) *Bitset {
+	if len(fields) != len(l.names) {
+		panic(fmt.Errorf("Layout has %d fields, but got %d bitsets.",
+			len(l.names), len(fields)))
+	}
+	for i, b := range fields {
+		if b.Len() != l.lengths[i] {
+			panic(fmt.Errorf("Field \"%s\" has length %d, but got bitset of length %d.",
+				l.names[i], l.lengths[i], b.Len()))
+		}
+	}
+	return Concat(fields...)
+}
+
+// Splits a bitset into one new bitset per field, in order.
+func (l Layout) Split(b Bitset) []*Bitset {
+	l.checkLength(b)
+	result := make([]*Bitset, len(l.names))
+	for i, offset := range l.offsets {
+		result[i] = b.Slice(offset, offset+l.lengths[i])
+	}
+	return result
+}
+
+// Creates a new bitset with the bits of the named field.
+func (l Layout) Get(b Bitset, name string) *Bitset {
+	l.checkLength(b)
+	offset, length, ok := l.Field(name)
+	if !ok {
+		panic(fmt.Errorf("Unknown field \"%s\" in layout.", name))
+	}
+	return b.Slice(offset, offset+length)
+}
+
+func (l Layout) checkLength(b Bitset) {
+	if b.Len() != l.length {
+		panic(fmt.Errorf("Layout has length %d, but got bitset of length %d.",
+			l.length, b.Len()))
+	}
+}
+
+func (l Layout) String() string {
+	return fmt.Sprintf("Layout%v@%v(len=%d)", l.names, l.offsets, l.length)
+}
@@ -0,0 +1,56 @@
+package data
+
+import "testing"
+
+func TestLayout(t *testing.T) {
+	l := NewLayout()
+	if err := l.Add("hour", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Add("value", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Add("hour", 3); err == nil {
+		t.Error("Should fail to add a duplicate field.")
+	}
+	if err := l.Add("empty", 0); err == nil {
+		t.Error("Should fail to add an empty field.")
+	}
+	ExpectEquals(t, "length", 110, l.Len())
+	ExpectEquals(t, "fields", 2, l.NumFields())
+	if offset, length, ok := l.Field("value"); !ok || offset != 10 || length != 100 {
+		t.Errorf("Bad field: offset=%d, length=%d, ok=%t", offset, length, ok)
+	}
+	if _, _, ok := l.Field("other"); ok {
+		t.Error("Should not find unknown field.")
+	}
+	if name, ok := l.FieldAt(10); !ok || name != "value" {
+		t.Errorf("Bad field at 10: %s", name)
+	}
+	if _, ok := l.FieldAt(110); ok {
+		t.Error("Should not find field past the end.")
+	}
+
+	hour := NewBitset(10).Set(1, 2)
+	value := NewBitset(100).Set(50, 51, 52)
+	joined := l.Join(*hour, *value)
+	ExpectEquals(t, "joined", "[0001,0002,0060,0061,0062]", joined.String())
+	parts := l.Split(*joined)
+	if !parts[0].Equals(*hour) || !parts[1].Equals(*value) {
+		t.Errorf("Split failed: %v", parts)
+	}
+	if !l.Get(*joined, "value").Equals(*value) {
+		t.Errorf("Get failed: %v", *l.Get(*joined, "value"))
+	}
+}
+
+func TestLayoutJoin_BadLength(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("Should have failed, but didn't.")
+		}
+	}()
+	l := NewLayout()
+	l.Add("a", 10)
+	l.Join(*NewBitset(11))
+}
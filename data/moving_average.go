@@ -0,0 +1,175 @@
+package data
+
+import "encoding/json"
+import "fmt"
+
+// A moving window average of float values. If you create a window of length N,
+// the (N+1)th value will overwrite the 0th value. Like CycleHistory, but for
+// values such as overlap scores instead of boolean events.
+type MovingAverage struct {
+	values []float32
+	// Position where the next value will be recorded.
+	next int
+	// Number of values recorded, up to len(values).
+	count int
+	// Running sum of the values in the window.
+	sum float64
+}
+
+// Creates a new moving average with the given window length.
+func NewMovingAverage(length int) *MovingAverage {
+	if length <= 0 {
+		panic(fmt.Errorf("Window length must be positive, but is %d.", length))
+	}
+	return &MovingAverage{
+		values: make([]float32, length),
+	}
+}
+
+// Records a new value.
+func (ma *MovingAverage) Record(value float32) {
+	if ma.count == len(ma.values) {
+		ma.sum -= float64(ma.values[ma.next])
+	} else {
+		ma.count++
+	}
+	ma.values[ma.next] = value
+	ma.sum += float64(value)
+	ma.next = (ma.next + 1) % len(ma.values)
+}
+
+// The length of the window.
+func (ma MovingAverage) Len() int {
+	return len(ma.values)
+}
+
+// Returns the average of the values in the last window, along with a boolean that
+// says whether the value can be used, to be used with the ok idiom:
+//
+// if val, ok := avg.Average(); ok && somePredicate(val) { ... }
+//
+// The only reason for ok to be false is when no value has ever been recorded.
+func (ma MovingAverage) Average() (result float32, ok bool) {
+	if ma.count == 0 {
+		return
+	}
+	return float32(ma.sum / float64(ma.count)), true
+}
+
+func (ma MovingAverage) String() string {
+	avg, _ := ma.Average()
+	return fmt.Sprintf("MovingAverage{len=%d, count=%d, avg=%f}",
+		len(ma.values), ma.count, avg)
+}
+
+type movingAverageJSON struct {
+	// Values in the order they were recorded, oldest first.
+	Values []float32 `json:"values"`
+	Length int       `json:"length"`
+}
+
+func (ma MovingAverage) MarshalJSON() ([]byte, error) {
+	result := movingAverageJSON{
+		Values: make([]float32, 0, ma.count),
+		Length: len(ma.values),
+	}
+	start := (ma.next - ma.count + len(ma.values)) % len(ma.values)
+	for i := 0; i < ma.count; i++ {
+		result.Values = append(result.Values, ma.values[(start+i)%len(ma.values)])
+	}
+	return json.Marshal(result)
+}
+
+func (ma *MovingAverage) UnmarshalJSON(buf []byte) error {
+	var in movingAverageJSON
+	if err := json.Unmarshal(buf, &in); err != nil {
+		return err
+	}
+	if in.Length <= 0 || len(in.Values) > in.Length {
+		return fmt.Errorf("Bad moving average: %d values for window length %d.",
+			len(in.Values), in.Length)
+	}
+	if in.Length > maxUnmarshalLength {
+		return fmt.Errorf("Window length %d is too large (max %d).", in.Length, maxUnmarshalLength)
+	}
+	*ma = MovingAverage{values: make([]float32, in.Length)}
+	for _, v := range in.Values {
+		ma.Record(v)
+	}
+	return nil
+}
+
+// An exponential moving average of float values. Each new value is weighed by
+// alpha = 2 / (period + 1), and the previous average by 1 - alpha.
+type ExponentialAverage struct {
+	period int
+	alpha  float32
+	value  float32
+	ok     bool
+}
+
+// Creates a new exponential moving average with the given period, i.e. the
+// number of values that carry most of the weight.
+func NewExponentialAverage(period int) *ExponentialAverage {
+	if period <= 0 {
+		panic(fmt.Errorf("Period must be positive, but is %d.", period))
+	}
+	return &ExponentialAverage{
+		period: period,
+		alpha:  2.0 / float32(period+1),
+	}
+}
+
+// Records a new value. The first value recorded becomes the average.
+func (ea *ExponentialAverage) Record(value float32) {
+	if !ea.ok {
+		ea.value = value
+		ea.ok = true
+		return
+	}
+	ea.value += ea.alpha * (value - ea.value)
+}
+
+// The period of this average.
+func (ea ExponentialAverage) Period() int {
+	return ea.period
+}
+
+// Returns the current average, with the ok idiom (see MovingAverage.Average()).
+func (ea ExponentialAverage) Average() (result float32, ok bool) {
+	return ea.value, ea.ok
+}
+
+func (ea ExponentialAverage) String() string {
+	return fmt.Sprintf("ExponentialAverage{period=%d, avg=%f, ok=%t}",
+		ea.period, ea.value, ea.ok)
+}
+
+type exponentialAverageJSON struct {
+	Period int      `json:"period"`
+	Value  *float32 `json:"value,omitempty"`
+}
+
+func (ea ExponentialAverage) MarshalJSON() ([]byte, error) {
+	result := exponentialAverageJSON{Period: ea.period}
+	if ea.ok {
+		result.Value = &ea.value
+	}
+	return json.Marshal(result)
+}
+
+func (ea *ExponentialAverage) UnmarshalJSON(buf []byte) error {
+	var in exponentialAverageJSON
+	if err := json.Unmarshal(buf, &in); err != nil {
+		return err
+	}
+	if in.Period <= 0 {
+		return fmt.Errorf("Period must be positive, but is %d.", in.Period)
+	}
+	*ea = *NewExponentialAverage(in.Period)
+	if in.Value != nil {
+		ea.value = *in.Value
+		ea.ok = true
+	}
+	return nil
+}
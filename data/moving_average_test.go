@@ -0,0 +1,130 @@
+package data
+
+import "encoding/json"
+import "testing"
+
+func TestMovingAverage(t *testing.T) {
+	ma := NewMovingAverage(4)
+	if avg, ok := ma.Average(); ok {
+		t.Errorf("Should not be ok: %f", avg)
+	}
+	ma.Record(2.0)
+	if avg, ok := ma.Average(); !ok || avg != 2.0 {
+		t.Errorf("Should be %f average: %f, ok=%t", 2.0, avg, ok)
+	}
+	ma.Record(4.0)
+	if avg, ok := ma.Average(); !ok || avg != 3.0 {
+		t.Errorf("Should be %f average: %f, ok=%t, %v", 3.0, avg, ok, ma)
+	}
+	ma.Record(0.0)
+	ma.Record(2.0)
+	if avg, ok := ma.Average(); !ok || avg != 2.0 {
+		t.Errorf("Should be %f average: %f, ok=%t, %v", 2.0, avg, ok, ma)
+	}
+	// Overwrites the first value (2.0).
+	ma.Record(6.0)
+	if avg, ok := ma.Average(); !ok || avg != 3.0 {
+		t.Errorf("Should be %f average: %f, ok=%t, %v", 3.0, avg, ok, ma)
+	}
+}
+
+func TestMovingAverageJSON(t *testing.T) {
+	ma := NewMovingAverage(3)
+	for _, v := range []float32{1, 2, 3, 4} {
+		ma.Record(v)
+	}
+	buf, err := json.Marshal(ma)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExpectEquals(t, "json", `{"values":[2,3,4],"length":3}`, string(buf))
+
+	other := new(MovingAverage)
+	if err = json.Unmarshal(buf, other); err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := ma.Average()
+	if avg, ok := other.Average(); !ok || avg != expected {
+		t.Errorf("Should be %f average: %f, ok=%t, %v", expected, avg, ok, other)
+	}
+	// The oldest value must be overwritten first after a round trip, too.
+	ma.Record(10)
+	other.Record(10)
+	expected, _ = ma.Average()
+	if avg, _ := other.Average(); avg != expected {
+		t.Errorf("Should be %f average: %f, %v", expected, avg, other)
+	}
+
+	if err = json.Unmarshal([]byte(`{"values":[1,2],"length":1}`), other); err == nil {
+		t.Error("Should fail with more values than the window length.")
+	}
+	if err = json.Unmarshal([]byte(`{"values":[1],"length":4611686018427387904}`), other); err == nil {
+		t.Error("Should fail with a huge window length.")
+	}
+	if err = json.Unmarshal([]byte(`{"values":[],"length":0}`), other); err == nil {
+		t.Error("Should fail with zero window length.")
+	}
+}
+
+func TestExponentialAverage(t *testing.T) {
+	ea := NewExponentialAverage(3)
+	if avg, ok := ea.Average(); ok {
+		t.Errorf("Should not be ok: %f", avg)
+	}
+	ea.Record(4.0)
+	if avg, ok := ea.Average(); !ok || avg != 4.0 {
+		t.Errorf("Should be %f average: %f, ok=%t", 4.0, avg, ok)
+	}
+	// alpha = 2 / (3 + 1) = 0.5
+	ea.Record(2.0)
+	if avg, ok := ea.Average(); !ok || avg != 3.0 {
+		t.Errorf("Should be %f average: %f, ok=%t, %v", 3.0, avg, ok, ea)
+	}
+	for i := 0; i < 100; i++ {
+		ea.Record(1.0)
+	}
+	if avg, _ := ea.Average(); avg < 0.999 || avg > 1.001 {
+		t.Errorf("Should converge to %f: %f, %v", 1.0, avg, ea)
+	}
+}
+
+func TestExponentialAverageJSON(t *testing.T) {
+	ea := NewExponentialAverage(10)
+	buf, err := json.Marshal(ea)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ExpectEquals(t, "empty json", `{"period":10}`, string(buf))
+	ea.Record(0.5)
+	if buf, err = json.Marshal(ea); err != nil {
+		t.Fatal(err)
+	}
+	ExpectEquals(t, "json", `{"period":10,"value":0.5}`, string(buf))
+
+	other := new(ExponentialAverage)
+	if err = json.Unmarshal(buf, other); err != nil {
+		t.Fatal(err)
+	}
+	if avg, ok := other.Average(); !ok || avg != 0.5 || other.Period() != 10 {
+		t.Errorf("Bad round trip: %v", other)
+	}
+	if err = json.Unmarshal([]byte(`{"period":0}`), other); err == nil {
+		t.Error("Should fail with zero period.")
+	}
+}
+
+func BenchmarkMovingAverage(b *testing.B) {
+	ma := NewMovingAverage(1000)
+	for i := 0; i < b.N; i++ {
+		ma.Record(float32(i % 100))
+		ma.Average()
+	}
+}
+
+func BenchmarkExponentialAverage(b *testing.B) {
+	ea := NewExponentialAverage(1000)
+	for i := 0; i < b.N; i++ {
+		ea.Record(float32(i % 100))
+		ea.Average()
+	}
+}
@@ -0,0 +1,227 @@
+// Sparse bitset implementation for HTM.
+//
+// Most bitsets in a region are around 2% dense, so it is often cheaper to keep
+// the sorted list of set indices than to scan every 64-bit word of a Bitset.
+
+package data
+
+import "fmt"
+import "sort"
+import "strings"
+
+// A sparse bitset, represented as a sorted list of the indices that are set.
+type SparseBitset struct {
+	// The set indices, in ascending order and without repetition.
+	indices []int
+	// The valid length of this bitset, in bits.
+	length int
+}
+
+// Creates a new, empty sparse bitset of the given length.
+func NewSparseBitset(length int) *SparseBitset {
+	return &SparseBitset{
+		indices: make([]int, 0, length/50+1),
+		length:  length,
+	}
+}
+
+// Creates a new sparse bitset with the same bits as the given bitset.
+func SparseBitsetFromBits(bits Bitset) *SparseBitset {
+	result := &SparseBitset{
+		indices: make([]int, 0, bits.NumSetBits()),
+		length:  bits.Len(),
+	}
+	bits.Foreach(func(i int) {
+		result.indices = append(result.indices, i)
+	})
+	return result
+}
+
+// Returns whether a bitset is cheaper to scan as a list of indices than as a
+// list of words, i.e. whether it has fewer set bits than 64-bit words.
+func PreferSparse(b Bitset) bool {
+	return b.NumSetBits() < len(b.binary)
+}
+
+func (s SparseBitset) Len() int {
+	return s.length
+}
+
+func (s SparseBitset) NumSetBits() int {
+	return len(s.indices)
+}
+
+func (s SparseBitset) IsZero() bool {
+	return len(s.indices) == 0
+}
+
+// Returns the set indices. The returned slice must not be modified.
+func (s SparseBitset) Indices() []int {
+	return s.indices
+}
+
+func (s SparseBitset) IsSet(index int) bool {
+	pos := sort.SearchInts(s.indices, index)
+	return pos < len(s.indices) && s.indices[pos] == index
+}
+
+func (s SparseBitset) Foreach(f func(int)) {
+	for _, v := range s.indices {
+		f(v)
+	}
+}
+
+func (s *SparseBitset) Reset() *SparseBitset {
+	s.indices = s.indices[0:0]
+	return s
+}
+
+// Resets this sparse bitset to the bits of a dense bitset of the same length.
+func (s *SparseBitset) ResetTo(bits Bitset) {
+	if s.length != bits.Len() {
+		panic(fmt.Errorf(
+			"Cannot ResetTo bitset of different length (%d != %d)", s.length, bits.Len()))
+	}
+	s.indices = s.indices[0:0]
+	bits.Foreach(func(i int) {
+		s.indices = append(s.indices, i)
+	})
+}
+
+func (s *SparseBitset) Set(indices ...int) *SparseBitset {
+	for _, v := range indices {
+		if v >= s.length {
+			panic(fmt.Errorf(
+				"Attempt to write past end of bitset (%d > %d)", v, s.length))
+		}
+		if v < 0 {
+			panic(fmt.Errorf(
+				"Attempt to write before start of bitset (%d < %d)", v, 0))
+		}
+		pos := sort.SearchInts(s.indices, v)
+		if pos < len(s.indices) && s.indices[pos] == v {
+			continue
+		}
+		s.indices = append(s.indices, 0)
+		copy(s.indices[pos+1:], s.indices[pos:])
+		s.indices[pos] = v
+	}
+	return s
+}
+
+func (s *SparseBitset) Unset(indices ...int) *SparseBitset {
+	for _, v := range indices {
+		pos := sort.SearchInts(s.indices, v)
+		if pos < len(s.indices) && s.indices[pos] == v {
+			s.indices = append(s.indices[:pos], s.indices[pos+1:]...)
+		}
+	}
+	return s
+}
+
+// Counts the bits that are set in both this sparse bitset and the given dense
+// bitset.
+func (s SparseBitset) Overlap(other Bitset) (count int) {
+	if s.length != other.Len() {
+		panic(fmt.Errorf(
+			"Cannot overlap bitsets of different length (%d != %d)", s.length, other.Len()))
+	}
+	for _, v := range s.indices {
+		if other.binary[v/64]&(1<<uint64(v%64)) != 0 {
+			count++
+		}
+	}
+	return
+}
+
+// Counts the bits that are set in both sparse bitsets.
+func (s SparseBitset) SparseOverlap(other SparseBitset) (count int) {
+	i, j := 0, 0
+	for i < len(s.indices) && j < len(other.indices) {
+		switch {
+		case s.indices[i] < other.indices[j]:
+			i++
+		case s.indices[i] > other.indices[j]:
+			j++
+		default:
+			count++
+			i++
+			j++
+		}
+	}
+	return
+}
+
+// Sets in this sparse bitset all the bits set in the other.
+func (s *SparseBitset) Or(other SparseBitset) {
+	if s.length != other.length {
+		panic(fmt.Errorf(
+			"Cannot OR bitsets of different length (%d != %d)", s.length, other.length))
+	}
+	merged := make([]int, 0, len(s.indices)+len(other.indices))
+	i, j := 0, 0
+	for i < len(s.indices) && j < len(other.indices) {
+		switch {
+		case s.indices[i] < other.indices[j]:
+			merged = append(merged, s.indices[i])
+			i++
+		case s.indices[i] > other.indices[j]:
+			merged = append(merged, other.indices[j])
+			j++
+		default:
+			merged = append(merged, s.indices[i])
+			i++
+			j++
+		}
+	}
+	merged = append(merged, s.indices[i:]...)
+	merged = append(merged, other.indices[j:]...)
+	s.indices = merged
+}
+
+// Sets in the dense bitset all the bits set in the sparse bitset.
+func (b *Bitset) OrSparse(other SparseBitset) {
+	if b.length != other.length {
+		panic(fmt.Errorf(
+			"Cannot OR bitsets of different length (%d != %d)", b.length, other.length))
+	}
+	for _, v := range other.indices {
+		b.binary[v/64] |= 1 << uint64(v%64)
+	}
+}
+
+// Converts this sparse bitset into a new dense bitset.
+func (s SparseBitset) ToBitset() *Bitset {
+	result := NewBitset(s.length)
+	result.OrSparse(s)
+	return result
+}
+
+func (s SparseBitset) Equals(other SparseBitset) bool {
+	if s.length != other.length || len(s.indices) != len(other.indices) {
+		return false
+	}
+	for i, v := range s.indices {
+		if v != other.indices[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s SparseBitset) Clone() *SparseBitset {
+	result := &SparseBitset{
+		indices: make([]int, len(s.indices)),
+		length:  s.length,
+	}
+	copy(result.indices, s.indices)
+	return result
+}
+
+func (s SparseBitset) String() string {
+	str := make([]string, len(s.indices))
+	for i, v := range s.indices {
+		str[i] = fmt.Sprintf("%04d", v)
+	}
+	return "[" + strings.Join(str, ",") + "]"
+}
@@ -0,0 +1,119 @@
+package data
+
+import "math/rand"
+import "testing"
+
+func TestSparseBitsetConversion(t *testing.T) {
+	b := NewBitset(2048).Set(1, 33, 63, 64, 2000)
+	s := SparseBitsetFromBits(*b)
+	ExpectEquals(t, "length", 2048, s.Len())
+	ExpectEquals(t, "num bits", 5, s.NumSetBits())
+	ExpectEquals(t, "string", b.String(), s.String())
+	if !s.ToBitset().Equals(*b) {
+		t.Errorf("Round trip failed. Expected: %v, but got: %v", *b, *s.ToBitset())
+	}
+	for _, v := range []int{1, 33, 63, 64, 2000} {
+		if !s.IsSet(v) {
+			t.Errorf("Bit %d should be set: %v", v, *s)
+		}
+	}
+	if s.IsSet(0) || s.IsSet(2047) {
+		t.Errorf("Bits 0 and 2047 should not be set: %v", *s)
+	}
+	last := -1
+	s.Foreach(func(i int) {
+		if i <= last {
+			t.Errorf("Bits must come in ascending order: %d <= %d", i, last)
+		}
+		last = i
+	})
+}
+
+func TestSparseBitsetSetAndUnset(t *testing.T) {
+	s := NewSparseBitset(100)
+	s.Set(50, 10, 90, 10)
+	ExpectEquals(t, "string", "[0010,0050,0090]", s.String())
+	s.Unset(50, 51)
+	ExpectEquals(t, "string", "[0010,0090]", s.String())
+	s.Reset()
+	if !s.IsZero() {
+		t.Errorf("Should be zero: %v", *s)
+	}
+	s.ResetTo(*NewBitset(100).Set(1, 2, 3))
+	ExpectEquals(t, "string", "[0001,0002,0003]", s.String())
+}
+
+func TestSparseBitsetSet_AfterLength(t *testing.T) {
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("Should have failed, but didn't.")
+		}
+	}()
+	NewSparseBitset(10).Set(10)
+}
+
+func TestSparseBitsetOverlap(t *testing.T) {
+	alpha := NewBitset(2048).Set(0, 2, 4, 10, 12, 14, 20, 22, 24)
+	beta := NewSparseBitset(2048).Set(2, 12, 22, 32)
+	ExpectEquals(t, "beta & alpha", 3, beta.Overlap(*alpha))
+	ExpectEquals(t, "alpha & beta", 3, SparseBitsetFromBits(*alpha).SparseOverlap(*beta))
+	beta.Set(4)
+	ExpectEquals(t, "beta & alpha", 4, beta.Overlap(*alpha))
+	ExpectEquals(t, "alpha & beta", 4, beta.SparseOverlap(*SparseBitsetFromBits(*alpha)))
+}
+
+func TestSparseBitsetOr(t *testing.T) {
+	s := NewSparseBitset(128).Set(1, 64, 100)
+	s.Or(*NewSparseBitset(128).Set(0, 64, 127))
+	ExpectEquals(t, "string", "[0000,0001,0064,0100,0127]", s.String())
+
+	b := NewBitset(128).Set(2)
+	b.OrSparse(*s)
+	ExpectEquals(t, "string", "[0000,0001,0002,0064,0100,0127]", b.String())
+}
+
+func TestPreferSparse(t *testing.T) {
+	b := NewBitset(2048).Set(1, 2, 3)
+	if !PreferSparse(*b) {
+		t.Errorf("Should prefer sparse form: %v", *b)
+	}
+	b.SetRange(0, 64)
+	if PreferSparse(*b) {
+		t.Errorf("Should prefer dense form: %v", *b)
+	}
+}
+
+func OverlapBenchmarkTemplate(b *testing.B, n, l int, sparse bool) {
+	rand.Seed(int64(1979))
+	input := NewBitset(n)
+	other := NewBitset(n)
+	for i := 0; i < l; i++ {
+		input.Set(rand.Intn(n))
+		other.Set(rand.Intn(n))
+	}
+	sparseInput := SparseBitsetFromBits(*input)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if sparse {
+			sparseInput.Overlap(*other)
+		} else {
+			input.Overlap(*other)
+		}
+	}
+}
+
+func BenchmarkDenseOverlap2048(b *testing.B) {
+	OverlapBenchmarkTemplate(b, 2048, 40, false)
+}
+
+func BenchmarkSparseOverlap2048(b *testing.B) {
+	OverlapBenchmarkTemplate(b, 2048, 40, true)
+}
+
+func BenchmarkDenseOverlap65536(b *testing.B) {
+	OverlapBenchmarkTemplate(b, 65536, 40, false)
+}
+
+func BenchmarkSparseOverlap65536(b *testing.B) {
+	OverlapBenchmarkTemplate(b, 65536, 40, true)
+}
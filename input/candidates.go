@@ -0,0 +1,104 @@
+package input
+
+import "sort"
+import "github.com/dukejeffrie/htm/data"
+
+// A decoded value, with the number of its bits that were set and its share of the
+// total overlap of all buckets, which works as a probability.
+type Candidate struct {
+	Value   interface{}
+	Overlap int
+	Score   float64
+}
+
+// Encoders that decode a bitset into a ranked list of values. Unlike Decode(),
+// this makes sense of unions of encodings, such as Region.PredictedInput(), where
+// every predicted value is a candidate.
+type CandidateDecoder interface {
+	// Returns up to k candidates with some overlap, best first. Ties are in bucket
+	// order.
+	DecodeCandidates(bits data.Bitset, k int) []Candidate
+}
+
+var _ CandidateDecoder = (*ScalarSensor)(nil)
+var _ CandidateDecoder = (*CategorySensor)(nil)
+var _ CandidateDecoder = (*PeriodicSensor)(nil)
+var _ CandidateDecoder = (*RandomDistributedScalarSensor)(nil)
+var _ CandidateDecoder = (*LogScalarSensor)(nil)
+var _ CandidateDecoder = (*RandomCategorySensor)(nil)
+var _ CandidateDecoder = (*SemanticCategorySensor)(nil)
+var _ CandidateDecoder = (*TokenSensor)(nil)
+
+// Ranks the buckets by overlap and returns up to k of them, using value to get the
+// value of each bucket.
+func rankCandidates(overlaps []int, k int, value func(bucket int) interface{}) []Candidate {
+	total := 0
+	buckets := make([]int, 0, len(overlaps))
+	for b, o := range overlaps {
+		if o > 0 {
+			total += o
+			buckets = append(buckets, b)
+		}
+	}
+	sort.SliceStable(buckets, func(i, j int) bool {
+		return overlaps[buckets[i]] > overlaps[buckets[j]]
+	})
+	if len(buckets) > k {
+		buckets = buckets[:k]
+	}
+	result := make([]Candidate, len(buckets))
+	for i, b := range buckets {
+		result[i] = Candidate{
+			Value:   value(b),
+			Overlap: overlaps[b],
+			Score:   float64(overlaps[b]) / float64(total),
+		}
+	}
+	return result
+}
+
+// Returns the bucket with the best overlap, the first one on ties.
+func bestBucket(overlaps []int) int {
+	best := 0
+	for b, o := range overlaps {
+		if o > overlaps[best] {
+			best = b
+		}
+	}
+	return best
+}
+
+// Returns the overlap of bits with each code.
+func codeOverlaps(bits data.Bitset, codes [][]int) []int {
+	result := make([]int, len(codes))
+	for b, code := range codes {
+		for _, v := range code {
+			if bits.IsSet(v) {
+				result[b]++
+			}
+		}
+	}
+	return result
+}
+
+// Returns the overlap of bits with each window of w contiguous bits, which are the
+// buckets of ScalarSensor.
+func windowOverlaps(bits data.Bitset, w, buckets int) []int {
+	result := make([]int, buckets)
+	count := 0
+	for i := 0; i < w-1; i++ {
+		if bits.IsSet(i) {
+			count++
+		}
+	}
+	for b := range result {
+		if bits.IsSet(b + w - 1) {
+			count++
+		}
+		result[b] = count
+		if bits.IsSet(b) {
+			count--
+		}
+	}
+	return result
+}
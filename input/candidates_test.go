@@ -0,0 +1,86 @@
+package input
+
+import "math"
+import "testing"
+import "github.com/dukejeffrie/htm/data"
+
+func TestDecodeCandidates_Scalar(t *testing.T) {
+	s, err := NewScalarSensor(64, 4, 0, 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A union of the predictions for 20 and 90.
+	union := data.NewBitset(s.N)
+	s.Encode(20)
+	union.Or(s.Get())
+	d20 := s.Decode(s.Get())
+	s.Encode(90)
+	union.Or(s.Get())
+	d90 := s.Decode(s.Get())
+	candidates := s.DecodeCandidates(*union, 2)
+	if len(candidates) != 2 {
+		t.Fatalf("Should have 2 candidates, but got: %v", candidates)
+	}
+	if candidates[0].Value != d20 || candidates[1].Value != d90 {
+		t.Errorf("Bad candidates: %v", candidates)
+	}
+	// Neighbors of each bucket also overlap: 1+2+3+4+3+2+1 bits for each value.
+	if candidates[0].Overlap != 4 || math.Abs(candidates[0].Score-4.0/32) > 1e-9 {
+		t.Errorf("Bad score for %v", candidates[0])
+	}
+	if all := s.DecodeCandidates(*union, 100); len(all) != 14 {
+		t.Errorf("Should have 14 candidates, but has %d: %v", len(all), all)
+	}
+	if v := s.Decode(*union); v != d20 {
+		t.Errorf("Decode should return the first best candidate, but got %v", v)
+	}
+}
+
+func TestDecodeCandidates_Category(t *testing.T) {
+	s, err := NewCategorySensor(12, 4, "red", "green", "blue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	union := data.NewBitset(s.N)
+	s.Encode("blue")
+	union.Or(s.Get())
+	s.Encode("green")
+	union.Or(s.Get())
+	union.Unset(4)
+	candidates := s.DecodeCandidates(*union, 3)
+	if len(candidates) != 2 || candidates[0].Value != "blue" || candidates[1].Value != "green" {
+		t.Errorf("Bad candidates: %v", candidates)
+	}
+	if v := s.Decode(*union); v != "blue" {
+		t.Errorf("Decode should pick blue, but got %v", v)
+	}
+}
+
+func TestDecodeCandidates_Periodic(t *testing.T) {
+	s, err := NewPeriodicSensorWithResolution(14, 2, 1, 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	union := data.NewBitset(s.N)
+	s.Encode(7)
+	union.Or(s.Get())
+	s.Encode(3)
+	union.Or(s.Get())
+	candidates := s.DecodeCandidates(*union, 5)
+	if len(candidates) != 2 || candidates[0].Value != 3 || candidates[1].Value != 7 ||
+		candidates[0].Score != 0.5 {
+		t.Errorf("Bad candidates: %v", candidates)
+	}
+}
+
+func TestDecodeCandidates_Log(t *testing.T) {
+	s, err := NewLogScalarSensor(3, 10, 1, 1e6, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Encode(5000)
+	candidates := s.DecodeCandidates(s.Get(), 1)
+	if len(candidates) != 1 || math.Abs(candidates[0].Value.(float64)-math.Pow(10, 3.5)) > 1e-6 {
+		t.Errorf("Bad candidates: %v", candidates)
+	}
+}
@@ -0,0 +1,199 @@
+package input
+
+import "fmt"
+import "sort"
+import "github.com/dukejeffrie/htm/data"
+
+// Encodes N-dimensional integer coordinates. Every coordinate within Radius of the
+// input (in each dimension) is hashed to an order and a bit; the W neighbors with
+// the highest order set their bits. Close coordinates share most of their
+// neighbors, so their encodings overlap, and the encoding is the same no matter
+// where the coordinate is, so there is no fixed range.
+type CoordinateSensor struct {
+	*Sensor
+	Dimensions int
+	Radius     int
+	Seed       int64
+
+	// The last encoded coordinate and the radius used to encode it, for Decode().
+	lastCoord  []int
+	lastRadius int
+}
+
+// Creates a new coordinate sensor of n bits with w active bits, for coordinates
+// with the given number of dimensions.
+func NewCoordinateSensor(n, w, dimensions, radius int, seed int64) (*CoordinateSensor, error) {
+	if w <= 0 || w >= n {
+		return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", w, n)
+	}
+	if dimensions <= 0 {
+		return nil, fmt.Errorf("Dimensions must be positive, but is %d.", dimensions)
+	}
+	result := &CoordinateSensor{
+		Sensor:     NewSensor(n, w),
+		Dimensions: dimensions,
+		Seed:       seed,
+	}
+	if err := result.checkRadius(radius); err != nil {
+		return nil, err
+	}
+	result.Radius = radius
+	return result, nil
+}
+
+// The neighborhood must have at least w coordinates to choose from.
+func (s CoordinateSensor) checkRadius(radius int) error {
+	if radius < 0 {
+		return fmt.Errorf("Radius must not be negative, but is %d.", radius)
+	}
+	count := 1
+	for i := 0; i < s.Dimensions && count < s.W; i++ {
+		count *= 2*radius + 1
+	}
+	if count < s.W {
+		return fmt.Errorf("Radius %d has fewer than w (%d) neighbors in %d dimensions.",
+			radius, s.W, s.Dimensions)
+	}
+	return nil
+}
+
+func (s CoordinateSensor) String() string {
+	return fmt.Sprint(*s.Sensor, "[", s.Dimensions, "d, r=", s.Radius, "]")
+}
+
+// Encodes a []int with Dimensions elements.
+func (s *CoordinateSensor) Encode(value interface{}) error {
+	switch value := value.(type) {
+	case []int:
+		return s.EncodeCoordinate(value, s.Radius)
+	default:
+		s.input = value
+		s.value.Reset()
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+// Encodes a coordinate with the given radius instead of Radius. A larger radius
+// makes the encodings of nearby coordinates overlap more.
+func (s *CoordinateSensor) EncodeCoordinate(coord []int, radius int) error {
+	s.input = coord
+	s.value.Reset()
+	if len(coord) != s.Dimensions {
+		return fmt.Errorf("Coordinate %v should have %d dimensions.", coord, s.Dimensions)
+	}
+	if err := s.checkRadius(radius); err != nil {
+		return err
+	}
+	s.value.Set(s.bitsFor(coord, radius)...)
+	s.lastCoord = append(s.lastCoord[:0], coord...)
+	s.lastRadius = radius
+	return nil
+}
+
+// Returns the bits of the W neighbors of coord with the highest order. Bits may
+// repeat when two neighbors hash to the same bit.
+func (s CoordinateSensor) bitsFor(coord []int, radius int) []int {
+	type neighbor struct {
+		order uint64
+		bit   int
+	}
+	neighbors := make([]neighbor, 0, 16)
+	forEachNeighbor(coord, radius, func(c []int) {
+		neighbors = append(neighbors, neighbor{
+			order: s.hash(c, orderSalt),
+			bit:   int(s.hash(c, bitSalt) % uint64(s.N)),
+		})
+	})
+	sort.Slice(neighbors, func(i, j int) bool {
+		return neighbors[i].order > neighbors[j].order
+	})
+	result := make([]int, s.W)
+	for i := range result {
+		result[i] = neighbors[i].bit
+	}
+	return result
+}
+
+// Salts to derive independent hashes for the order and bit of a coordinate.
+const (
+	orderSalt = 0x6f72646572
+	bitSalt   = 0x626974
+)
+
+// Hashes a coordinate, mixing each component with the splitmix64 finalizer.
+func (s CoordinateSensor) hash(coord []int, salt uint64) uint64 {
+	h := uint64(s.Seed) ^ salt
+	for _, v := range coord {
+		h = mix64(h ^ uint64(int64(v)))
+	}
+	return h
+}
+
+func mix64(h uint64) uint64 {
+	h += 0x9e3779b97f4a7c15
+	h = (h ^ (h >> 30)) * 0xbf58476d1ce4e5b9
+	h = (h ^ (h >> 27)) * 0x94d049bb133111eb
+	return h ^ (h >> 31)
+}
+
+// Calls fn with every coordinate within radius of center in each dimension. The
+// slice passed to fn is reused between calls.
+func forEachNeighbor(center []int, radius int, fn func([]int)) {
+	c := make([]int, len(center))
+	for i := range c {
+		c[i] = center[i] - radius
+	}
+	for {
+		fn(c)
+		i := 0
+		for ; i < len(c); i++ {
+			if c[i] < center[i]+radius {
+				c[i]++
+				break
+			}
+			c[i] = center[i] - radius
+		}
+		if i == len(c) {
+			return
+		}
+	}
+}
+
+// Decodes the bits into the coordinate with the best overlap, searching within
+// Radius of the last encoded coordinate with the last used radius. The search
+// costs (2r+1)^(2d) hashes, so it is only practical for small neighborhoods.
+// Returns nil if nothing was encoded yet.
+func (s CoordinateSensor) Decode(bits data.Bitset) interface{} {
+	if s.lastCoord == nil {
+		return nil
+	}
+	var best []int
+	bestOverlap := -1
+	forEachNeighbor(s.lastCoord, s.Radius, func(c []int) {
+		overlap := 0
+		for _, b := range s.bitsFor(c, s.lastRadius) {
+			if bits.IsSet(b) {
+				overlap++
+			}
+		}
+		if overlap > bestOverlap {
+			best = append(best[:0], c...)
+			bestOverlap = overlap
+		}
+	})
+	return best
+}
+
+// Coordinates have no buckets.
+func (s CoordinateSensor) Bucket(value interface{}) (int, error) {
+	return 0, fmt.Errorf("Coordinates have no buckets.")
+}
+
+func (s CoordinateSensor) NumBuckets() int {
+	return 0
+}
+
+func (s CoordinateSensor) Description() string {
+	return fmt.Sprintf("coordinate(n=%d, w=%d, dimensions=%d, radius=%d)",
+		s.N, s.W, s.Dimensions, s.Radius)
+}
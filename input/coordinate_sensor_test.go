@@ -0,0 +1,81 @@
+package input
+
+import "testing"
+import "github.com/dukejeffrie/htm/data"
+
+func TestCoordinateSensor(t *testing.T) {
+	s, err := NewCoordinateSensor(1024, 21, 2, 5, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(coord ...int) *data.Bitset {
+		if err := s.Encode(coord); err != nil {
+			t.Fatal(err)
+		}
+		return s.Get().Clone()
+	}
+	a := encode(100, 200)
+	if a.NumSetBits() < 18 {
+		t.Errorf("Too few bits set (collisions?): %v", a)
+	}
+	same := encode(100, 200)
+	if !same.Equals(*a) {
+		t.Errorf("Encoding is not deterministic: %v != %v", same, a)
+	}
+	near := encode(101, 200)
+	far := encode(150, 250)
+	if a.Overlap(*near) < 10 {
+		t.Errorf("Neighbors should overlap: %v vs %v", a, near)
+	}
+	if a.Overlap(*near) <= a.Overlap(*far) {
+		t.Errorf("Far coordinates overlap more than near ones: %v vs %v", near, far)
+	}
+	encode(101, 201)
+	if decoded := s.Decode(*a).([]int); decoded[0] != 100 || decoded[1] != 200 {
+		t.Errorf("Bad decoded coordinate: %v", decoded)
+	}
+	other, _ := NewCoordinateSensor(1024, 21, 2, 5, 43)
+	other.Encode([]int{100, 200})
+	if other.Get().Equals(*a) {
+		t.Errorf("Different seeds should give different encodings.")
+	}
+}
+
+func TestCoordinateSensor_Errors(t *testing.T) {
+	if _, err := NewCoordinateSensor(100, 21, 2, 1, 0); err == nil {
+		t.Error("Should fail when the radius has fewer than w neighbors.")
+	}
+	if _, err := NewCoordinateSensor(100, 21, 0, 5, 0); err == nil {
+		t.Error("Should fail without dimensions.")
+	}
+	s, _ := NewCoordinateSensor(100, 21, 2, 5, 0)
+	if err := s.Encode([]int{1, 2, 3}); err == nil {
+		t.Error("Should fail with the wrong number of dimensions.")
+	}
+	if err := s.Encode(1.5); err == nil {
+		t.Error("Should fail to encode a non-coordinate.")
+	}
+	if s.Decode(s.Get()) != nil {
+		t.Error("Should not decode before encoding.")
+	}
+}
+
+func TestCoordinateSensor_Field(t *testing.T) {
+	s, err := NewCoordinateSensor(256, 9, 2, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Bucket([]int{1, 2}); err == nil || s.NumBuckets() != 0 {
+		t.Error("Coordinates should have no buckets.")
+	}
+	m, err := NewMultiEncoder(Field{"position", s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Encode(map[string]interface{}{"position": []int{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := m.Get().NumSetBits(); n == 0 || n > 9 {
+		t.Errorf("Bad encoding: %v", m.Get())
+	}
+}
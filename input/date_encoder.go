@@ -0,0 +1,180 @@
+package input
+
+import "bytes"
+import "fmt"
+import "time"
+
+// A holiday in a calendar. If Year is zero, the holiday happens every year.
+type Holiday struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+func (h Holiday) matches(t time.Time) bool {
+	year, month, day := t.Date()
+	return h.Month == month && h.Day == day && (h.Year == 0 || h.Year == year)
+}
+
+// Parameters to describe a date encoder. Each field is the number of bits for that
+// part of the encoding; zero leaves that part out.
+type DateEncoderParameters struct {
+	// Hour of the day, [0, 23], periodic.
+	TimeOfDay int
+	// Day of the week, Sunday to Saturday, periodic.
+	DayOfWeek int
+	// Whether the day is Saturday or Sunday.
+	Weekend int
+	// Month of the year, periodic so that December is next to January.
+	Season int
+	// Whether the day is in Holidays.
+	Holiday int
+	// The local holiday calendar.
+	Holidays []Holiday
+}
+
+// Names of the fields in the DateEncoder layout and decoded records.
+const (
+	TimeOfDayField = "timeOfDay"
+	DayOfWeekField = "dayOfWeek"
+	WeekendField   = "weekend"
+	SeasonField    = "season"
+	HolidayField   = "holiday"
+)
+
+// Encodes a time.Time as the concatenation of the parts enabled in its parameters.
+type DateEncoder struct {
+	*MultiEncoder
+	DateEncoderParameters
+
+	// The last encoded time.
+	input time.Time
+}
+
+// Creates a new date encoder with the given parameters.
+func NewDateEncoder(params DateEncoderParameters) (*DateEncoder, error) {
+	fields := make([]Field, 0, 5)
+	addPeriodic := func(name string, n, first, last int) error {
+		if n <= 0 {
+			return nil
+		}
+		// Neighboring values share about half of their bits.
+		buckets := last - first + 1
+		s, err := NewPeriodicSensorWithResolution(n, 2*((n+buckets-1)/buckets)+1, first, last, 1)
+		if err != nil {
+			return fmt.Errorf("Bad %s encoder: %v", name, err)
+		}
+		fields = append(fields, Field{name, s})
+		return nil
+	}
+	addFlag := func(name string, n int, no, yes string) error {
+		if n <= 0 {
+			return nil
+		}
+		if n < 2 {
+			return fmt.Errorf("Bad %s encoder: needs at least 2 bits, but has %d.", name, n)
+		}
+		s, err := NewCategorySensor(n, n/2, no, yes)
+		if err != nil {
+			return fmt.Errorf("Bad %s encoder: %v", name, err)
+		}
+		fields = append(fields, Field{name, s})
+		return nil
+	}
+	if err := addPeriodic(TimeOfDayField, params.TimeOfDay, 0, 23); err != nil {
+		return nil, err
+	}
+	if err := addPeriodic(DayOfWeekField, params.DayOfWeek, int(time.Sunday), int(time.Saturday)); err != nil {
+		return nil, err
+	}
+	if err := addFlag(WeekendField, params.Weekend, "weekday", "weekend"); err != nil {
+		return nil, err
+	}
+	if err := addPeriodic(SeasonField, params.Season, int(time.January), int(time.December)); err != nil {
+		return nil, err
+	}
+	if err := addFlag(HolidayField, params.Holiday, "regular", "holiday"); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("DateEncoder needs at least one part with a positive width.")
+	}
+	m, err := NewMultiEncoder(fields...)
+	if err != nil {
+		return nil, err
+	}
+	return &DateEncoder{MultiEncoder: m, DateEncoderParameters: params}, nil
+}
+
+// Encodes a time.Time.
+func (d *DateEncoder) Encode(value interface{}) error {
+	switch value := value.(type) {
+	case time.Time:
+		return d.EncodeTime(value)
+	default:
+		d.input = time.Time{}
+		d.value.Reset()
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+func (d *DateEncoder) EncodeTime(t time.Time) error {
+	d.input = t
+	return d.EncodeRecord(d.Record(t))
+}
+
+// Returns the record that would be encoded for a time. Parts that are not
+// enabled are left out.
+func (d DateEncoder) Record(t time.Time) map[string]interface{} {
+	result := make(map[string]interface{}, 5)
+	if d.TimeOfDay > 0 {
+		result[TimeOfDayField] = t.Hour()
+	}
+	if d.DayOfWeek > 0 {
+		result[DayOfWeekField] = int(t.Weekday())
+	}
+	if d.Weekend > 0 {
+		if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			result[WeekendField] = "weekend"
+		} else {
+			result[WeekendField] = "weekday"
+		}
+	}
+	if d.Season > 0 {
+		result[SeasonField] = int(t.Month())
+	}
+	if d.Holiday > 0 {
+		result[HolidayField] = "regular"
+		for _, h := range d.Holidays {
+			if h.matches(t) {
+				result[HolidayField] = "holiday"
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Returns the last encoded time.Time.
+func (d DateEncoder) Raw() interface{} {
+	return d.input
+}
+
+// Dates have no single bucket. Each part has its own, see MultiEncoder.Encoder().
+func (d DateEncoder) Bucket(value interface{}) (int, error) {
+	return 0, fmt.Errorf("Dates have no single bucket.")
+}
+
+func (d DateEncoder) NumBuckets() int {
+	return 0
+}
+
+func (d DateEncoder) Description() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "date(n=%d", d.Width())
+	for _, f := range d.Fields() {
+		fmt.Fprintf(&buf, ", %s=%s", f.Name, f.Encoder.Description())
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
@@ -0,0 +1,114 @@
+package input
+
+import "testing"
+import "time"
+
+func TestDateEncoder(t *testing.T) {
+	d, err := NewDateEncoder(DateEncoderParameters{
+		TimeOfDay: 30,
+		DayOfWeek: 10,
+		Weekend:   4,
+		Season:    16,
+		Holiday:   4,
+		Holidays:  []Holiday{{Month: time.December, Day: 25}, {2015, time.July, 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Width() != 64 {
+		t.Errorf("Width should be %d, but is %d", 64, d.Width())
+	}
+	// A Wednesday.
+	when := time.Date(2015, time.March, 11, 14, 30, 0, 0, time.UTC)
+	if err := d.Encode(when); err != nil {
+		t.Fatal(err)
+	}
+	if d.Raw() != when {
+		t.Errorf("Bad raw value: %v", d.Raw())
+	}
+	if d.Get().NumSetBits() != 5+5+2+5+2 {
+		t.Errorf("Bad encoding: %v", d.Get())
+	}
+	decoded := d.DecodeRecord(d.Get())
+	expected := map[string]interface{}{
+		TimeOfDayField: 14,
+		DayOfWeekField: int(time.Wednesday),
+		WeekendField:   "weekday",
+		SeasonField:    int(time.March),
+		HolidayField:   "regular",
+	}
+	for k, v := range expected {
+		if decoded[k] != v {
+			t.Errorf("Bad decoded %s. Expected: %v, but got: %v", k, v, decoded[k])
+		}
+	}
+}
+
+func TestDateEncoder_Flags(t *testing.T) {
+	d, err := NewDateEncoder(DateEncoderParameters{
+		Weekend:  4,
+		Holiday:  4,
+		Holidays: []Holiday{{Month: time.December, Day: 25}, {2015, time.July, 3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Width() != 8 {
+		t.Errorf("Width should be %d, but is %d", 8, d.Width())
+	}
+	cases := []struct {
+		when             time.Time
+		weekend, holiday string
+	}{
+		{time.Date(2015, time.December, 25, 0, 0, 0, 0, time.UTC), "weekday", "holiday"},
+		{time.Date(2016, time.December, 25, 0, 0, 0, 0, time.UTC), "weekend", "holiday"},
+		{time.Date(2015, time.July, 3, 0, 0, 0, 0, time.UTC), "weekday", "holiday"},
+		{time.Date(2016, time.July, 3, 0, 0, 0, 0, time.UTC), "weekend", "regular"},
+	}
+	for _, c := range cases {
+		if err := d.Encode(c.when); err != nil {
+			t.Fatal(err)
+		}
+		decoded := d.DecodeRecord(d.Get())
+		if decoded[WeekendField] != c.weekend || decoded[HolidayField] != c.holiday {
+			t.Errorf("Bad flags for %v: %v", c.when, decoded)
+		}
+	}
+}
+
+func TestDateEncoder_Errors(t *testing.T) {
+	if _, err := NewDateEncoder(DateEncoderParameters{}); err == nil {
+		t.Error("Should fail without any parts.")
+	}
+	if _, err := NewDateEncoder(DateEncoderParameters{TimeOfDay: 10}); err == nil {
+		t.Error("Should fail when the time of day does not fit.")
+	}
+	if _, err := NewDateEncoder(DateEncoderParameters{Weekend: 1}); err == nil {
+		t.Error("Should fail with a 1-bit flag.")
+	}
+	d, _ := NewDateEncoder(DateEncoderParameters{DayOfWeek: 10})
+	if err := d.Encode("monday"); err == nil {
+		t.Error("Should fail to encode a non-time.")
+	}
+}
+
+func TestDateEncoder_Field(t *testing.T) {
+	d, err := NewDateEncoder(DateEncoderParameters{TimeOfDay: 30, DayOfWeek: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Bucket(time.Now()); err == nil || d.NumBuckets() != 0 {
+		t.Error("Dates should have no single bucket.")
+	}
+	m, err := NewMultiEncoder(Field{"when", d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	when := time.Date(2015, time.July, 3, 14, 0, 0, 0, time.UTC)
+	if err := m.Encode(map[string]interface{}{"when": when}); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Get().Equals(d.Get()) {
+		t.Errorf("Bad encoding. Expected: %v, but got: %v", d.Get(), m.Get())
+	}
+}
@@ -0,0 +1,135 @@
+package input
+
+import "fmt"
+import "math"
+import "github.com/dukejeffrie/htm/data"
+
+// Encodes the change from the previous value instead of the value itself, for
+// signals that are only meaningful as rates of change. Optionally, the value is
+// also encoded next to the change. Call Reset() at sequence boundaries, so that
+// the first value of a sequence is not compared to the last one of the previous.
+type DeltaSensor struct {
+	*Sensor
+	// Encodes the difference from the previous value. The first value after a
+	// Reset() has a difference of 0.
+	Delta *ScalarSensor
+	// Encodes the value itself after the difference, or nil.
+	Absolute *ScalarSensor
+
+	previous    float64
+	hasPrevious bool
+}
+
+// Creates a new delta sensor. The absolute sensor can be nil.
+func NewDeltaSensor(delta, absolute *ScalarSensor) (*DeltaSensor, error) {
+	if delta == nil {
+		return nil, fmt.Errorf("DeltaSensor needs a sensor for the difference.")
+	}
+	n, w := delta.N, delta.W
+	if absolute != nil {
+		n, w = n+absolute.N, w+absolute.W
+	}
+	result := &DeltaSensor{
+		Sensor:   NewSensor(n, w),
+		Delta:    delta,
+		Absolute: absolute,
+	}
+	return result, nil
+}
+
+func (s DeltaSensor) String() string {
+	return fmt.Sprint(*s.Sensor, "[delta from ", s.previous, "]")
+}
+
+// Forgets the previous value.
+func (s *DeltaSensor) Reset() {
+	s.hasPrevious = false
+}
+
+// The previous value, with the ok idiom.
+func (s DeltaSensor) Previous() (float64, bool) {
+	return s.previous, s.hasPrevious
+}
+
+func (s *DeltaSensor) Encode(value interface{}) error {
+	switch value := value.(type) {
+	case int:
+		return s.EncodeFloat(float64(value))
+	case float64:
+		return s.EncodeFloat(value)
+	default:
+		s.input = value
+		s.value.Reset()
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+// Encodes the difference from the previous value. The value only becomes the
+// previous one if it is encoded, so that one outlier (or NaN) does not also spoil
+// the next difference.
+func (s *DeltaSensor) EncodeFloat(value float64) error {
+	s.input = value
+	s.value.Reset()
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("Cannot encode %v.", value)
+	}
+	delta := 0.0
+	if s.hasPrevious {
+		delta = value - s.previous
+	}
+	if err := s.Delta.Encode(delta); err != nil {
+		return err
+	}
+	s.value.SetFromBitsetAt(s.Delta.Get(), 0)
+	if s.Absolute != nil {
+		if err := s.Absolute.Encode(value); err != nil {
+			s.value.Reset()
+			return err
+		}
+		s.value.SetFromBitsetAt(s.Absolute.Get(), s.Delta.N)
+	}
+	s.previous, s.hasPrevious = value, true
+	return nil
+}
+
+func (s *DeltaSensor) EncodeInt(value int) error {
+	return s.EncodeFloat(float64(value))
+}
+
+// Decodes the bits into the difference, as a float64.
+func (s DeltaSensor) Decode(bits data.Bitset) interface{} {
+	return s.Delta.Decode(*bits.Slice(0, s.Delta.N))
+}
+
+// Decodes the bits into the value that follows the previous one, which is what a
+// prediction of the next input means.
+func (s DeltaSensor) DecodeNext(bits data.Bitset) float64 {
+	return s.previous + s.Decode(bits).(float64)
+}
+
+// Decodes the absolute value, with the ok idiom. Not ok if there is no Absolute
+// sensor.
+func (s DeltaSensor) DecodeAbsolute(bits data.Bitset) (float64, bool) {
+	if s.Absolute == nil {
+		return 0, false
+	}
+	return s.Absolute.Decode(*bits.Slice(s.Delta.N, s.N)).(float64), true
+}
+
+// Returns the bucket of a difference, which is what Decode() returns, so that
+// Bucket(Decode(bits)) is the bucket that was encoded.
+func (s DeltaSensor) Bucket(value interface{}) (int, error) {
+	return s.Delta.Bucket(value)
+}
+
+func (s DeltaSensor) NumBuckets() int {
+	return s.Delta.NumBuckets()
+}
+
+func (s DeltaSensor) Description() string {
+	if s.Absolute == nil {
+		return fmt.Sprintf("delta(n=%d, delta=%s)", s.N, s.Delta.Description())
+	}
+	return fmt.Sprintf("delta(n=%d, delta=%s, absolute=%s)", s.N,
+		s.Delta.Description(), s.Absolute.Description())
+}
@@ -0,0 +1,105 @@
+package input
+
+import "math"
+import "testing"
+
+func TestDeltaSensor(t *testing.T) {
+	delta, _ := NewScalarSensor(44, 4, -20, 21)
+	s, err := NewDeltaSensor(delta, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Encode(100); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.Decode(s.Get()).(float64); v < 0 || v >= 1 {
+		t.Errorf("The first value should have a difference of 0, but got %v", v)
+	}
+	if err := s.Encode(105); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.Decode(s.Get()).(float64); v < 5 || v >= 6 {
+		t.Errorf("Bad difference: %v", v)
+	}
+	up := s.Get().Clone()
+	encoded, _ := s.Delta.Bucket(5.0)
+	if b, err := s.Bucket(s.Decode(s.Get())); err != nil || b != encoded {
+		t.Errorf("Bucket(Decode()) should be the encoded bucket %d, but got %d (%v)", encoded, b, err)
+	}
+	s.Encode(110)
+	if !s.Get().Equals(*up) {
+		t.Errorf("The same change should have the same encoding: %v vs %v", s.Get(), up)
+	}
+	if v := s.DecodeNext(*up); v < 115 || v >= 116 {
+		t.Errorf("Bad next value: %v", v)
+	}
+	if err := s.Encode(300); err == nil {
+		t.Error("Should fail with a difference out of range.")
+	}
+	if p, ok := s.Previous(); !ok || p != 110 {
+		t.Errorf("The outlier should not become the previous value: %v, %v", p, ok)
+	}
+	s.Reset()
+	if _, ok := s.Previous(); ok {
+		t.Error("Reset should forget the previous value.")
+	}
+	s.Encode(-1000)
+	if v := s.Decode(s.Get()).(float64); v < 0 || v >= 1 {
+		t.Errorf("The first value after a reset should have a difference of 0, but got %v", v)
+	}
+}
+
+func TestDeltaSensor_Absolute(t *testing.T) {
+	delta, _ := NewScalarSensor(44, 4, -20, 21)
+	absolute, _ := NewScalarSensor(64, 4, 0, 120)
+	s, err := NewDeltaSensor(delta, absolute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Width() != 108 || s.W != 8 {
+		t.Errorf("Bad size: %v", *s)
+	}
+	s.Encode(60)
+	s.Encode(50)
+	if s.Get().NumSetBits() != 8 {
+		t.Errorf("Should have %d bits set, but has %d", 8, s.Get().NumSetBits())
+	}
+	if v := s.Decode(s.Get()).(float64); v < -10 || v >= -9 {
+		t.Errorf("Bad difference: %v", v)
+	}
+	if v, ok := s.DecodeAbsolute(s.Get()); !ok || v < 50 || v >= 52 {
+		t.Errorf("Bad absolute value: %v", v)
+	}
+	if err := s.Encode(500); err == nil {
+		t.Error("Should fail with an absolute value out of range.")
+	}
+	if _, err := NewDeltaSensor(nil, absolute); err == nil {
+		t.Error("Should fail without a delta sensor.")
+	}
+}
+
+func TestDeltaSensor_Outliers(t *testing.T) {
+	delta, _ := NewScalarSensor(44, 4, -20, 21)
+	s, _ := NewDeltaSensor(delta, nil)
+	// Only the outlier fails; the values after it are compared to the one before.
+	for _, v := range []float64{1, 1000, 2, 3} {
+		err := s.EncodeFloat(v)
+		if (err != nil) != (v == 1000) {
+			t.Errorf("Encode(%v) failed: %v", v, err)
+		}
+	}
+	if v := s.Decode(s.Get()).(float64); v < 1 || v >= 2 {
+		t.Errorf("Bad difference after the outlier: %v", v)
+	}
+	for _, bad := range []float64{math.NaN(), math.Inf(1)} {
+		if err := s.EncodeFloat(bad); err == nil {
+			t.Errorf("Should fail to encode %v", bad)
+		}
+	}
+	if p, ok := s.Previous(); !ok || p != 3 {
+		t.Errorf("NaN and Inf should not become the previous value: %v, %v", p, ok)
+	}
+	if err := s.EncodeFloat(4); err != nil {
+		t.Errorf("Encode(4) after NaN failed: %v", err)
+	}
+}
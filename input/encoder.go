@@ -0,0 +1,40 @@
+package input
+
+import "github.com/dukejeffrie/htm/data"
+
+// Common interface for all the sensors, so that networks, classifiers and tools
+// can treat encoders generically.
+type Encoder interface {
+	// Encodes a value, which is then available through Get().
+	data.Encoder
+	// Decodes a bitset back into a value in the original domain.
+	data.Decoder
+
+	// The number of bits in each encoding.
+	Width() int
+	// The number of distinct buckets this encoder can produce.
+	NumBuckets() int
+	// Returns the bucket that a value falls into, or an error if the value
+	// cannot be encoded.
+	Bucket(value interface{}) (int, error)
+	// A human-readable description of the encoder and its parameters.
+	Description() string
+	// Gets the last value passed to Encode().
+	Raw() interface{}
+}
+
+var _ Encoder = (*ScalarSensor)(nil)
+var _ Encoder = (*CategorySensor)(nil)
+var _ Encoder = (*PeriodicSensor)(nil)
+var _ Encoder = (*RandomDistributedScalarSensor)(nil)
+var _ Encoder = (*LogScalarSensor)(nil)
+var _ Encoder = (*RandomCategorySensor)(nil)
+var _ Encoder = (*SemanticCategorySensor)(nil)
+var _ Encoder = (*TokenSensor)(nil)
+var _ Encoder = (*DeltaSensor)(nil)
+var _ Encoder = (*MissingValueEncoder)(nil)
+var _ Encoder = (*PassThroughSensor)(nil)
+var _ Encoder = (*ImageSensor)(nil)
+var _ Encoder = (*CoordinateSensor)(nil)
+var _ Encoder = (*GeospatialSensor)(nil)
+var _ Encoder = (*DateEncoder)(nil)
@@ -0,0 +1,62 @@
+package input
+
+import "strings"
+import "testing"
+
+func TestEncoderInterface(t *testing.T) {
+	scalar, _ := NewScalarSensor(64, 4, 0, 120)
+	category, _ := NewCategorySensor(64, 4, "A", "B", "C")
+	periodic, _ := NewPeriodicSensorWithResolution(64, 4, 1, 7, 1)
+	tests := []struct {
+		encoder    Encoder
+		values     []interface{}
+		numBuckets int
+		desc       string
+	}{
+		{scalar, []interface{}{0, 60.0, 119}, 61, "scalar"},
+		{category, []interface{}{"A", "B", "C"}, 3, "category"},
+		{periodic, []interface{}{1, 4, 7}, 7, "periodic"},
+	}
+	for _, test := range tests {
+		e := test.encoder
+		if e.Width() != 64 {
+			t.Errorf("%s: width should be 64, but is %d", e.Description(), e.Width())
+		}
+		if e.NumBuckets() != test.numBuckets {
+			t.Errorf("%s: should have %d buckets, but has %d",
+				e.Description(), test.numBuckets, e.NumBuckets())
+		}
+		if !strings.HasPrefix(e.Description(), test.desc) {
+			t.Errorf("Bad description: %s", e.Description())
+		}
+		last := -1
+		for _, v := range test.values {
+			bucket, err := e.Bucket(v)
+			if err != nil {
+				t.Errorf("%s: %v", e.Description(), err)
+				continue
+			}
+			if bucket <= last || bucket >= e.NumBuckets() {
+				t.Errorf("%s: bad bucket %d for %v", e.Description(), bucket, v)
+			}
+			last = bucket
+			if err = e.Encode(v); err != nil {
+				t.Errorf("%s: %v", e.Description(), err)
+			}
+			if e.Raw() != v {
+				t.Errorf("%s: raw value should be %v, but is %v", e.Description(), v, e.Raw())
+			}
+			if e.Get().Len() != e.Width() {
+				t.Errorf("%s: encoding has length %d", e.Description(), e.Get().Len())
+			}
+			again, _ := e.Bucket(e.Decode(e.Get()))
+			if again != bucket {
+				t.Errorf("%s: decoded %v into bucket %d, expected %d",
+					e.Description(), v, again, bucket)
+			}
+		}
+		if _, err := e.Bucket(struct{}{}); err == nil {
+			t.Errorf("%s: should fail to bucket a struct", e.Description())
+		}
+	}
+}
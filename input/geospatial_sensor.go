@@ -0,0 +1,138 @@
+package input
+
+import "fmt"
+import "math"
+import "github.com/dukejeffrie/htm/data"
+
+// A position and the speed of an asset, as sensed by a GeospatialSensor.
+type GeoPoint struct {
+	// In degrees.
+	Latitude, Longitude float64
+	// In meters per second.
+	Speed float64
+}
+
+// Radius of the sphere used by the Web Mercator projection, in meters.
+const earthRadius = 6378137.0
+
+// Web Mercator is undefined at the poles, so latitudes are clipped to this value.
+const maxLatitude = 85.05112878
+
+// Encodes positions by projecting latitude and longitude to a 2-d coordinate grid
+// and encoding the cell with a CoordinateSensor. The cells grow with the speed, so
+// that consecutive positions of a moving asset still overlap: the faster it moves,
+// the coarser the encoding. The radius stays the same, so every encoding costs the
+// same.
+type GeospatialSensor struct {
+	*CoordinateSensor
+	// Meters per coordinate when standing still.
+	Scale float64
+	// Seconds between consecutive readings.
+	Timestep float64
+
+	// The scale used for the last encoding, for Decode().
+	lastScale float64
+}
+
+// Creates a new geospatial sensor of n bits with w active bits.
+func NewGeospatialSensor(n, w int, scale, timestep float64, seed int64) (*GeospatialSensor, error) {
+	if scale <= 0 || timestep <= 0 {
+		return nil, fmt.Errorf("Scale (%f) and timestep (%f) must be positive.", scale, timestep)
+	}
+	if w <= 0 || w >= n {
+		return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", w, n)
+	}
+	coordinates, err := NewCoordinateSensor(n, w, 2, minRadius(w), seed)
+	if err != nil {
+		return nil, err
+	}
+	result := &GeospatialSensor{
+		CoordinateSensor: coordinates,
+		Scale:            scale,
+		Timestep:         timestep,
+	}
+	return result, nil
+}
+
+// The smallest radius with at least w cells in 2 dimensions.
+func minRadius(w int) int {
+	return int(math.Ceil((math.Sqrt(float64(w)) - 1) / 2))
+}
+
+func (s GeospatialSensor) String() string {
+	return fmt.Sprint(*s.Sensor, "[", s.Scale, "m/", s.Timestep, "s]")
+}
+
+// Encodes a GeoPoint.
+func (s *GeospatialSensor) Encode(value interface{}) error {
+	switch value := value.(type) {
+	case GeoPoint:
+		return s.EncodePoint(value)
+	default:
+		s.input = value
+		s.value.Reset()
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+func (s *GeospatialSensor) EncodePoint(p GeoPoint) error {
+	if math.IsNaN(p.Latitude) || math.IsNaN(p.Longitude) || math.IsNaN(p.Speed) || p.Speed < 0 {
+		s.input = p
+		s.value.Reset()
+		return fmt.Errorf("Cannot encode %v.", p)
+	}
+	scale := s.ScaleForSpeed(p.Speed)
+	err := s.EncodeCoordinate(s.Coordinate(p.Latitude, p.Longitude, scale), s.Radius)
+	s.input = p
+	s.lastScale = scale
+	return err
+}
+
+// Returns the grid cell of a position, with cells of scale meters.
+func (s GeospatialSensor) Coordinate(latitude, longitude, scale float64) []int {
+	x, y := mercator(latitude, longitude)
+	return []int{int(math.Floor(x / scale)), int(math.Floor(y / scale))}
+}
+
+// Returns the meters per coordinate for an asset moving at the given speed, so
+// that it moves at most half the radius in one timestep. The result is Scale times
+// a power of two, so that similar speeds share the same grid and still overlap.
+func (s GeospatialSensor) ScaleForSpeed(speed float64) float64 {
+	cellsPerStep := speed * s.Timestep / s.Scale
+	maxCells := math.Max(float64(s.Radius)/2, 1)
+	if cellsPerStep <= maxCells {
+		return s.Scale
+	}
+	return s.Scale * math.Exp2(math.Ceil(math.Log2(cellsPerStep/maxCells)))
+}
+
+func mercator(latitude, longitude float64) (x, y float64) {
+	latitude = math.Max(-maxLatitude, math.Min(maxLatitude, latitude))
+	x = earthRadius * longitude * math.Pi / 180
+	y = earthRadius * math.Log(math.Tan(math.Pi/4+latitude*math.Pi/360))
+	return
+}
+
+func inverseMercator(x, y float64) (latitude, longitude float64) {
+	longitude = x / earthRadius * 180 / math.Pi
+	latitude = (2*math.Atan(math.Exp(y/earthRadius)) - math.Pi/2) * 180 / math.Pi
+	return
+}
+
+// Decodes the bits into a GeoPoint at the center of the best matching cell, see
+// CoordinateSensor.Decode(). The speed cannot be decoded, so it is zero. Returns
+// nil if nothing was encoded yet.
+func (s GeospatialSensor) Decode(bits data.Bitset) interface{} {
+	coord, ok := s.CoordinateSensor.Decode(bits).([]int)
+	if !ok {
+		return nil
+	}
+	lat, lon := inverseMercator((float64(coord[0])+0.5)*s.lastScale,
+		(float64(coord[1])+0.5)*s.lastScale)
+	return GeoPoint{Latitude: lat, Longitude: lon}
+}
+
+func (s GeospatialSensor) Description() string {
+	return fmt.Sprintf("geospatial(n=%d, w=%d, scale=%vm, timestep=%vs)",
+		s.N, s.W, s.Scale, s.Timestep)
+}
@@ -0,0 +1,75 @@
+package input
+
+import "math"
+import "testing"
+
+func TestGeospatialSensor(t *testing.T) {
+	s, err := NewGeospatialSensor(1024, 21, 30, 5, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Radius != 2 {
+		t.Errorf("Radius should be %d, but is %d", 2, s.Radius)
+	}
+	if scale := s.ScaleForSpeed(0); scale != 30 {
+		t.Errorf("Scale at rest should be %v, but is %v", 30, scale)
+	}
+	if s.ScaleForSpeed(100) <= s.ScaleForSpeed(10) {
+		t.Errorf("Scale should grow with speed: %v vs %v",
+			s.ScaleForSpeed(100), s.ScaleForSpeed(10))
+	}
+	if scale := s.ScaleForSpeed(10); scale != 60 {
+		t.Errorf("Scale should be Scale times a power of two: %v", scale)
+	}
+	walking := GeoPoint{Latitude: 37.7749, Longitude: -122.4194, Speed: 1.5}
+	if err := s.Encode(walking); err != nil {
+		t.Fatal(err)
+	}
+	if s.Raw() != walking {
+		t.Errorf("Bad raw value: %v", s.Raw())
+	}
+	at := s.Get().Clone()
+	decoded := s.Decode(*at).(GeoPoint)
+	if math.Abs(decoded.Latitude-walking.Latitude) > 0.001 ||
+		math.Abs(decoded.Longitude-walking.Longitude) > 0.001 {
+		t.Errorf("Bad decoded position: %v", decoded)
+	}
+	// About 100m to the east.
+	nearby := walking
+	nearby.Longitude += 0.00114
+	s.Encode(nearby)
+	slow := s.Get().Overlap(*at)
+	walking.Speed, nearby.Speed = 30, 30
+	s.Encode(walking)
+	at = s.Get().Clone()
+	s.Encode(nearby)
+	fast := s.Get().Overlap(*at)
+	if fast <= slow {
+		t.Errorf("Moving fast should overlap more (%d) than walking (%d).", fast, slow)
+	}
+	if err := s.Encode(GeoPoint{Speed: -1}); err == nil {
+		t.Error("Should fail with a negative speed.")
+	}
+}
+
+func TestGeospatialSensor_Fast(t *testing.T) {
+	s, err := NewGeospatialSensor(1024, 21, 1, 1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plane := GeoPoint{Latitude: 37.7749, Longitude: -122.4194, Speed: 300}
+	if err := s.Encode(plane); err != nil {
+		t.Fatal(err)
+	}
+	at := s.Get().Clone()
+	// One timestep later, about 300m to the east.
+	plane.Longitude += 0.00342
+	s.Encode(plane)
+	if overlap := s.Get().Overlap(*at); overlap < s.W/2 {
+		t.Errorf("Consecutive positions should overlap, but only share %d bits.", overlap)
+	}
+	decoded := s.Decode(s.Get()).(GeoPoint)
+	if math.Abs(decoded.Longitude-plane.Longitude) > 0.01 {
+		t.Errorf("Bad decoded position: %v", decoded)
+	}
+}
@@ -0,0 +1,183 @@
+package input
+
+import "fmt"
+import "image"
+import "image/color"
+import _ "image/png"
+import "math"
+import "os"
+import "github.com/dukejeffrie/htm/data"
+
+// How an ImageSensor turns gray levels into bits.
+type ImageMode int
+
+const (
+	// Sets the bits of pixels darker than Threshold, like ink on paper.
+	ImageBinarize ImageMode = iota
+	// Sets the bits of pixels where the gradient (Sobel operator) is at least
+	// Threshold, which outlines the shapes in the image.
+	ImageEdges
+)
+
+func (m ImageMode) String() string {
+	switch m {
+	case ImageBinarize:
+		return "binarize"
+	case ImageEdges:
+		return "edges"
+	default:
+		return fmt.Sprintf("ImageMode(%d)", int(m))
+	}
+}
+
+// Encodes images as a Rows x Cols grid with one bit per pixel, see data.Grid.
+// Images of other sizes are scaled to fit, picking the nearest pixel. Images can
+// be given as an image.Image or as the path of a PNG or PGM file.
+type ImageSensor struct {
+	*Sensor
+	Rows, Cols int
+	Mode       ImageMode
+	// In gray levels, [0, 255]. For ImageEdges, the gradient is scaled to the same
+	// range.
+	Threshold float64
+	// Sets the bits of the pixels that do not pass the threshold instead.
+	Invert bool
+}
+
+// Creates a new image sensor of rows x cols bits. W is the number of pixels, since
+// there is no telling how many will be set.
+func NewImageSensor(rows, cols int, mode ImageMode, threshold float64) (*ImageSensor, error) {
+	if rows <= 0 || cols <= 0 {
+		return nil, fmt.Errorf("Bad image size %dx%d.", rows, cols)
+	}
+	if threshold < 0 || threshold > 255 {
+		return nil, fmt.Errorf("Threshold must be in [0, 255], but is %f.", threshold)
+	}
+	result := &ImageSensor{
+		Sensor:    NewSensor(rows*cols, rows*cols),
+		Rows:      rows,
+		Cols:      cols,
+		Mode:      mode,
+		Threshold: threshold,
+	}
+	return result, nil
+}
+
+func (s ImageSensor) String() string {
+	return fmt.Sprint(*s.Sensor, "[", s.Rows, "x", s.Cols, " ", s.Mode, "]")
+}
+
+// Encodes an image.Image, or the image in the file at a path (string).
+func (s *ImageSensor) Encode(value interface{}) error {
+	s.input = value
+	s.value.Reset()
+	switch value := value.(type) {
+	case image.Image:
+		return s.EncodeImage(value)
+	case string:
+		return s.EncodeFile(value)
+	default:
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+// Reads and encodes a PNG or PGM file.
+func (s *ImageSensor) EncodeFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return fmt.Errorf("Cannot decode image %s: %v", path, err)
+	}
+	return s.EncodeImage(img)
+}
+
+func (s *ImageSensor) EncodeImage(img image.Image) error {
+	if img.Bounds().Empty() {
+		return fmt.Errorf("Cannot encode an empty image.")
+	}
+	gray := s.grayLevels(img)
+	if s.Mode == ImageEdges {
+		gray = s.gradient(gray)
+	}
+	for i, v := range gray {
+		on := v >= s.Threshold
+		if s.Mode == ImageBinarize {
+			on = v < s.Threshold
+		}
+		if on != s.Invert {
+			s.value.Set(i)
+		}
+	}
+	return nil
+}
+
+// Returns the gray level of each cell of the grid, scaling the image to fit.
+func (s ImageSensor) grayLevels(img image.Image) []float64 {
+	bounds := img.Bounds()
+	result := make([]float64, s.N)
+	for r := 0; r < s.Rows; r++ {
+		y := bounds.Min.Y + r*bounds.Dy()/s.Rows
+		for c := 0; c < s.Cols; c++ {
+			x := bounds.Min.X + c*bounds.Dx()/s.Cols
+			result[r*s.Cols+c] = float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+	}
+	return result
+}
+
+// Returns the magnitude of the Sobel gradient of each cell, divided by 4 so that a
+// sharp black to white edge is 255. Borders repeat the nearest cell.
+func (s ImageSensor) gradient(gray []float64) []float64 {
+	at := func(r, c int) float64 {
+		r = min(max(r, 0), s.Rows-1)
+		c = min(max(c, 0), s.Cols-1)
+		return gray[r*s.Cols+c]
+	}
+	result := make([]float64, len(gray))
+	for r := 0; r < s.Rows; r++ {
+		for c := 0; c < s.Cols; c++ {
+			gx := at(r-1, c+1) + 2*at(r, c+1) + at(r+1, c+1) -
+				at(r-1, c-1) - 2*at(r, c-1) - at(r+1, c-1)
+			gy := at(r+1, c-1) + 2*at(r+1, c) + at(r+1, c+1) -
+				at(r-1, c-1) - 2*at(r-1, c) - at(r-1, c+1)
+			result[r*s.Cols+c] = math.Hypot(gx, gy) / 4
+		}
+	}
+	return result
+}
+
+// The last encoding with its shape. The grid shares the bits of the sensor, so it
+// changes with the next Encode().
+func (s ImageSensor) Grid() *data.Grid {
+	return &data.Grid{Rows: s.Rows, Cols: s.Cols, Bits: s.value}
+}
+
+// Decodes the bits into an *image.Gray of Cols x Rows, with black set bits on a
+// white background (the other way around with Invert).
+func (s ImageSensor) Decode(bits data.Bitset) interface{} {
+	result := image.NewGray(image.Rect(0, 0, s.Cols, s.Rows))
+	for i := range result.Pix {
+		if bits.IsSet(i) == s.Invert {
+			result.Pix[i] = 255
+		}
+	}
+	return result
+}
+
+// Images have no buckets.
+func (s ImageSensor) Bucket(value interface{}) (int, error) {
+	return 0, fmt.Errorf("Images have no buckets.")
+}
+
+func (s ImageSensor) NumBuckets() int {
+	return 0
+}
+
+func (s ImageSensor) Description() string {
+	return fmt.Sprintf("image(%dx%d, mode=%v, threshold=%v, invert=%t)",
+		s.Rows, s.Cols, s.Mode, s.Threshold, s.Invert)
+}
@@ -0,0 +1,151 @@
+package input
+
+import "image"
+import "image/color"
+import "image/png"
+import "os"
+import "path/filepath"
+import "testing"
+import "github.com/dukejeffrie/htm/data"
+
+// A white 4x4 image with a black 2x2 square at the top left.
+func testSquare() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			img.SetGray(x, y, color.Gray{0})
+		}
+	}
+	return img
+}
+
+func TestImageSensor_Binarize(t *testing.T) {
+	s, err := NewImageSensor(4, 4, ImageBinarize, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Encode(testSquare()); err != nil {
+		t.Fatal(err)
+	}
+	expected := data.NewGrid(4, 4).Set(0, 0).Set(0, 1).Set(1, 0).Set(1, 1)
+	if grid := s.Grid(); !grid.Equals(*expected) {
+		t.Errorf("Bad encoding. Expected: %v, but got: %v", expected, grid)
+	}
+	decoded := s.Decode(s.Get()).(*image.Gray)
+	for i, v := range testSquare().Pix {
+		if decoded.Pix[i] != v {
+			t.Errorf("Bad decoded pixel %d. Expected: %d, but got: %d", i, v, decoded.Pix[i])
+		}
+	}
+
+	s.Invert = true
+	if err := s.Encode(testSquare()); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Get().NumSetBits(); n != 12 || s.Get().IsSet(0) {
+		t.Errorf("Inverted encoding should set the 12 white pixels: %v", s.Get())
+	}
+}
+
+func TestImageSensor_Scale(t *testing.T) {
+	s, err := NewImageSensor(2, 2, ImageBinarize, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Encode(testSquare()); err != nil {
+		t.Fatal(err)
+	}
+	expected := data.NewGrid(2, 2).Set(0, 0)
+	if grid := s.Grid(); !grid.Equals(*expected) {
+		t.Errorf("Bad scaled encoding. Expected: %v, but got: %v", expected, grid)
+	}
+}
+
+func TestImageSensor_Edges(t *testing.T) {
+	s, err := NewImageSensor(4, 4, ImageEdges, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Encode(testSquare()); err != nil {
+		t.Fatal(err)
+	}
+	grid := s.Grid()
+	for _, cell := range [][2]int{{0, 1}, {1, 1}, {1, 2}, {2, 1}} {
+		if !grid.IsSet(cell[0], cell[1]) {
+			t.Errorf("Edge at %v should be set: %v", cell, grid)
+		}
+	}
+	for _, cell := range [][2]int{{0, 0}, {3, 3}} {
+		if grid.IsSet(cell[0], cell[1]) {
+			t.Errorf("Flat area at %v should not be set: %v", cell, grid)
+		}
+	}
+}
+
+func TestImageSensor_Files(t *testing.T) {
+	dir := t.TempDir()
+	pgm := append([]byte("P5\n4 4\n255\n"), testSquare().Pix...)
+	pgmPath := filepath.Join(dir, "square.pgm")
+	if err := os.WriteFile(pgmPath, pgm, 0644); err != nil {
+		t.Fatal(err)
+	}
+	pngPath := filepath.Join(dir, "square.png")
+	f, err := os.Create(pngPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(f, testSquare()); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	s, err := NewImageSensor(4, 4, ImageBinarize, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := data.NewGrid(4, 4).Set(0, 0).Set(0, 1).Set(1, 0).Set(1, 1)
+	for _, path := range []string{pgmPath, pngPath} {
+		if err := s.Encode(path); err != nil {
+			t.Errorf("Could not encode %s: %v", path, err)
+			continue
+		}
+		if grid := s.Grid(); !grid.Equals(*expected) {
+			t.Errorf("Bad encoding of %s. Expected: %v, but got: %v", path, expected, grid)
+		}
+	}
+	if err := s.Encode(filepath.Join(dir, "missing.png")); err == nil {
+		t.Error("Should fail with a missing file.")
+	}
+	if err := s.Encode(42); err == nil {
+		t.Error("Should fail with a bad type.")
+	}
+}
+
+func TestImageSensor_Tiles(t *testing.T) {
+	s, err := NewImageSensor(4, 4, ImageBinarize, 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Encode(testSquare()); err != nil {
+		t.Fatal(err)
+	}
+	tiles, err := s.Grid().Tiles(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tiles) != 4 || tiles[0].Bits.NumSetBits() != 4 || tiles[3].Bits.NumSetBits() != 0 {
+		t.Errorf("Bad tiles: %v", tiles)
+	}
+}
+
+func TestNewImageSensor_Errors(t *testing.T) {
+	if _, err := NewImageSensor(0, 4, ImageBinarize, 128); err == nil {
+		t.Error("Should fail with no rows.")
+	}
+	if _, err := NewImageSensor(4, 4, ImageEdges, 300); err == nil {
+		t.Error("Should fail with a threshold out of range.")
+	}
+}
@@ -0,0 +1,133 @@
+package input
+
+import "fmt"
+import "math"
+import "github.com/dukejeffrie/htm/data"
+
+// A scalar sensor with logarithmic buckets, for values that span orders of
+// magnitude (latencies, byte counts). Values are encoded by a ScalarSensor over
+// [log(MinValue), log(MaxValue)) in the given base, so every bucket covers the same
+// ratio between values instead of the same difference.
+//
+// The embedded MinValue, MaxValue and BucketSize are in log space: a sensor for
+// [1, 1e6) in base 10 has MinValue 0 and MaxValue 6. Use math.Pow(Base, MinValue)
+// for the bounds in linear space.
+type LogScalarSensor struct {
+	// Encodes the logarithm of the values.
+	*ScalarSensor
+	Base float64
+}
+
+// Creates a new logarithmic scalar sensor for values in [min, max). The resolution
+// is the width of each bucket in log units, e.g. with base 10 a resolution of 0.1
+// gives ten buckets per order of magnitude. The number of bits is derived from the
+// range, resolution and w.
+func NewLogScalarSensor(w int, base, min, max, resolution float64) (*LogScalarSensor, error) {
+	if base <= 1.0 {
+		return nil, fmt.Errorf("Base must be greater than 1, but is %f.", base)
+	}
+	if min <= 0 || max <= min {
+		return nil, fmt.Errorf("Need 0 < min (%f) < max (%f).", min, max)
+	}
+	if resolution <= 0 {
+		return nil, fmt.Errorf("Resolution must be positive, but is %f.", resolution)
+	}
+	logMin, logMax := logBase(min, base), logBase(max, base)
+	buckets := int(math.Ceil((logMax - logMin) / resolution))
+	if n := buckets + w - 1; w <= 0 || w >= n {
+		return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", w, n)
+	}
+	result := &LogScalarSensor{
+		ScalarSensor: &ScalarSensor{
+			Sensor:     NewSensor(buckets+w-1, w),
+			MinValue:   logMin,
+			MaxValue:   logMax,
+			BucketSize: (logMax - logMin) / float64(buckets),
+		},
+		Base: base,
+	}
+	return result, nil
+}
+
+func logBase(value, base float64) float64 {
+	// The specialized functions are exact at powers of the base, so the bucket
+	// boundaries fall where expected.
+	switch base {
+	case 10:
+		return math.Log10(value)
+	case 2:
+		return math.Log2(value)
+	default:
+		return math.Log(value) / math.Log(base)
+	}
+}
+
+func (s LogScalarSensor) String() string {
+	return fmt.Sprint(*s.Sensor, "[", s.Base, "^", s.MinValue, "..", s.Base, "^",
+		s.MaxValue, "/", s.BucketSize, "]")
+}
+
+func (s *LogScalarSensor) Encode(value interface{}) error {
+	s.input = value
+	s.value.Reset()
+	switch value := value.(type) {
+	case int:
+		return s.EncodeFloat(float64(value))
+	case float64:
+		return s.EncodeFloat(value)
+	default:
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+func (s *LogScalarSensor) EncodeFloat(value float64) error {
+	if value <= 0 {
+		return fmt.Errorf("Cannot encode non-positive value %f on a log scale.", value)
+	}
+	return s.ScalarSensor.EncodeFloat(logBase(value, s.Base))
+}
+
+func (s *LogScalarSensor) EncodeInt(value int) error {
+	return s.EncodeFloat(float64(value))
+}
+
+func (s LogScalarSensor) Bucket(value interface{}) (int, error) {
+	var v float64
+	switch value := value.(type) {
+	case int:
+		v = float64(value)
+	case float64:
+		v = value
+	default:
+		return 0, fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("Cannot encode non-positive value %f on a log scale.", v)
+	}
+	return s.floatBucket(logBase(v, s.Base))
+}
+
+// Decodes the bits into the value at the center of the bucket, in log scale, like
+// ScalarSensor.Decode().
+func (s LogScalarSensor) Decode(bits data.Bitset) interface{} {
+	return math.Pow(s.Base, s.ScalarSensor.Decode(bits).(float64))
+}
+
+// Like ScalarSensor.DecodeCandidates(), with the values out of log scale.
+func (s LogScalarSensor) DecodeCandidates(bits data.Bitset, k int) []Candidate {
+	result := s.ScalarSensor.DecodeCandidates(bits, k)
+	for i := range result {
+		result[i].Value = math.Pow(s.Base, result[i].Value.(float64))
+	}
+	return result
+}
+
+func (s LogScalarSensor) DecodeInt(bits data.Bitset) int {
+	return int(math.Floor(s.Decode(bits).(float64)))
+}
+
+func (s LogScalarSensor) Description() string {
+	return fmt.Sprintf("log scalar(n=%d, w=%d, base=%v, range=[%v, %v), bucket=%v)",
+		s.N, s.W, s.Base, math.Pow(s.Base, s.MinValue), math.Pow(s.Base, s.MaxValue),
+		s.BucketSize)
+}
@@ -0,0 +1,68 @@
+package input
+
+import "math"
+import "testing"
+
+func TestLogScalarSensor(t *testing.T) {
+	s, err := NewLogScalarSensor(3, 10, 1, 1e6, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NumBuckets() != 60 || s.N != 62 {
+		t.Errorf("Should have 60 buckets in 62 bits: %v", *s)
+	}
+	for _, test := range []struct {
+		value  interface{}
+		bucket int
+	}{{1, 0}, {1.2, 0}, {10, 10}, {100.0, 20}, {999999, 59}} {
+		if err = s.Encode(test.value); err != nil {
+			t.Error(err)
+			continue
+		}
+		if b, _ := s.Bucket(test.value); b != test.bucket {
+			t.Errorf("Bucket of %v should be %d, but is %d", test.value, test.bucket, b)
+		}
+		if !s.Get().AllSet(test.bucket, test.bucket+1, test.bucket+2) ||
+			s.Get().NumSetBits() != 3 {
+			t.Errorf("Encode(%v) failed: %v", test.value, s.Get())
+		}
+	}
+
+	s.Encode(1000)
+	v := s.Decode(s.Get()).(float64)
+	if v < 1000 || v >= math.Pow(10, 3.1) {
+		t.Errorf("Decode failed. Expected a value in bucket [1000, %f), but got: %f",
+			math.Pow(10, 3.1), v)
+	}
+	if s.DecodeInt(s.Get()) != int(v) {
+		t.Errorf("DecodeInt failed: %d", s.DecodeInt(s.Get()))
+	}
+
+	for _, bad := range []interface{}{0, -1.0, 1e6, 0.5, "x"} {
+		if err = s.Encode(bad); err == nil {
+			t.Errorf("Should fail to encode %v", bad)
+		}
+	}
+}
+
+func TestLogScalarSensor_BadParameters(t *testing.T) {
+	if _, err := NewLogScalarSensor(3, 1, 1, 100, 0.1); err == nil {
+		t.Error("Should fail with base 1.")
+	}
+	if _, err := NewLogScalarSensor(3, 10, 0, 100, 0.1); err == nil {
+		t.Error("Should fail with min 0.")
+	}
+	if _, err := NewLogScalarSensor(3, 10, 1, 100, 0); err == nil {
+		t.Error("Should fail with zero resolution.")
+	}
+	if _, err := NewLogScalarSensor(0, 10, 1, 100, 0.1); err == nil {
+		t.Error("Should fail with w=0.")
+	}
+	if _, err := NewLogScalarSensor(-3, 10, 1, 100, 0.1); err == nil {
+		t.Error("Should fail with negative w.")
+	}
+	// A single bucket leaves no room for w < n.
+	if _, err := NewLogScalarSensor(3, 10, 1, 10, 1); err == nil {
+		t.Error("Should fail with w >= n.")
+	}
+}
@@ -0,0 +1,153 @@
+package input
+
+import "fmt"
+import "math"
+import "github.com/dukejeffrie/htm/data"
+
+// What a MissingValueEncoder does with missing values.
+type MissingPolicy int
+
+const (
+	// Returns an error, like any value the encoder cannot handle.
+	MissingError MissingPolicy = iota
+	// Sets no bits. Regions step over empty inputs, see Region.ConsumeMissing().
+	MissingEmpty
+	// Sets a dedicated pattern of bits, so regions can learn about gaps.
+	MissingPattern
+	// Encodes the last value again. Before any value, it sets no bits.
+	MissingCarryForward
+)
+
+func (p MissingPolicy) String() string {
+	switch p {
+	case MissingError:
+		return "error"
+	case MissingEmpty:
+		return "empty"
+	case MissingPattern:
+		return "pattern"
+	case MissingCarryForward:
+		return "carry forward"
+	default:
+		return fmt.Sprintf("MissingPolicy(%d)", int(p))
+	}
+}
+
+// Returns whether a value is missing: nil, or a NaN float64.
+func IsMissing(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case float64:
+		return math.IsNaN(value)
+	default:
+		return false
+	}
+}
+
+// Wraps an encoder to handle missing values (see IsMissing) with a policy.
+// Other values go to the wrapped encoder.
+type MissingValueEncoder struct {
+	Encoder
+	Policy MissingPolicy
+	// The bits of MissingPattern. Defaults to 2% of the width, evenly spaced.
+	Pattern []int
+
+	// The encoding of the last value, if it was missing.
+	value   *data.Bitset
+	missing bool
+	input   interface{}
+	last    interface{}
+	hasLast bool
+}
+
+// Wraps an encoder with a missing value policy.
+func NewMissingValueEncoder(e Encoder, policy MissingPolicy) *MissingValueEncoder {
+	width := e.Width()
+	pattern := make([]int, (width+49)/50)
+	for i := range pattern {
+		pattern[i] = i * width / len(pattern)
+	}
+	return &MissingValueEncoder{
+		Encoder: e,
+		Policy:  policy,
+		Pattern: pattern,
+		value:   data.NewBitset(width),
+	}
+}
+
+func (m *MissingValueEncoder) Encode(value interface{}) error {
+	m.input = value
+	m.missing = IsMissing(value)
+	if !m.missing {
+		err := m.Encoder.Encode(value)
+		if err == nil {
+			m.last, m.hasLast = value, true
+		}
+		return err
+	}
+	m.value.Reset()
+	switch m.Policy {
+	case MissingEmpty:
+	case MissingPattern:
+		m.value.Set(m.Pattern...)
+	case MissingCarryForward:
+		if m.hasLast {
+			m.missing = false
+			return m.Encoder.Encode(m.last)
+		}
+	default:
+		return fmt.Errorf("Missing value: %v", value)
+	}
+	return nil
+}
+
+func (m MissingValueEncoder) Get() data.Bitset {
+	if m.missing {
+		return *m.value
+	}
+	return m.Encoder.Get()
+}
+
+func (m MissingValueEncoder) Raw() interface{} {
+	return m.input
+}
+
+// Decodes empty bits, and bits that mostly match the pattern of MissingPattern,
+// into nil. Other bits are decoded by the wrapped encoder.
+func (m MissingValueEncoder) Decode(bits data.Bitset) interface{} {
+	if bits.IsZero() {
+		return nil
+	}
+	if m.Policy == MissingPattern {
+		overlap := 0
+		for _, v := range m.Pattern {
+			if bits.IsSet(v) {
+				overlap++
+			}
+		}
+		if 2*overlap > len(m.Pattern) && 2*overlap >= bits.NumSetBits() {
+			return nil
+		}
+	}
+	return m.Encoder.Decode(bits)
+}
+
+// Missing values have no bucket, unless they are carried forward.
+func (m MissingValueEncoder) Bucket(value interface{}) (int, error) {
+	if !IsMissing(value) {
+		return m.Encoder.Bucket(value)
+	}
+	if m.Policy == MissingCarryForward && m.hasLast {
+		return m.Encoder.Bucket(m.last)
+	}
+	return 0, fmt.Errorf("Missing value %v has no bucket.", value)
+}
+
+func (m MissingValueEncoder) Description() string {
+	return fmt.Sprintf("missing(policy=%v, %s)", m.Policy, m.Encoder.Description())
+}
+
+func (m MissingValueEncoder) String() string {
+	return fmt.Sprint(">>", m.input, "=", m.Get())
+}
@@ -0,0 +1,93 @@
+package input
+
+import "math"
+import "testing"
+
+func TestMissingValueEncoder(t *testing.T) {
+	scalar, _ := NewScalarSensor(100, 4, 0, 97)
+	m := NewMissingValueEncoder(scalar, MissingError)
+	if err := m.Encode(nil); err == nil {
+		t.Error("Should fail by default.")
+	}
+	if err := m.Encode(50); err != nil {
+		t.Fatal(err)
+	}
+	fifty := m.Get().Clone()
+	if m.Decode(*fifty) != 50.5 {
+		t.Errorf("Bad decoded value: %v", m.Decode(*fifty))
+	}
+
+	m.Policy = MissingEmpty
+	if err := m.Encode(math.NaN()); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Get().IsZero() || m.Get().Len() != 100 {
+		t.Errorf("Should encode nothing: %v", m.Get())
+	}
+	if m.Decode(m.Get()) != nil {
+		t.Errorf("Should decode as missing: %v", m.Decode(m.Get()))
+	}
+
+	m.Policy = MissingPattern
+	m.Encode(nil)
+	if !m.Get().AllSet(0, 50) || m.Get().NumSetBits() != 2 {
+		t.Errorf("Bad missing pattern: %v", m.Get())
+	}
+	if m.Decode(m.Get()) != nil {
+		t.Errorf("Should decode the pattern as missing: %v", m.Decode(m.Get()))
+	}
+	if m.Decode(*fifty) != 50.5 {
+		t.Errorf("Should still decode values: %v", m.Decode(*fifty))
+	}
+	if _, err := m.Bucket(nil); err == nil {
+		t.Error("Missing values should have no bucket.")
+	}
+
+	m.Policy = MissingCarryForward
+	if err := m.Encode(nil); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Get().Equals(*fifty) {
+		t.Errorf("Should carry 50 forward: %v", m.Get())
+	}
+	if m.Raw() != nil {
+		t.Errorf("Raw should be the missing value, but is %v", m.Raw())
+	}
+	if b, err := m.Bucket(nil); err != nil || b != 50 {
+		t.Errorf("Missing values should have the last bucket: %d, %v", b, err)
+	}
+	empty := NewMissingValueEncoder(scalar, MissingCarryForward)
+	if err := empty.Encode(nil); err != nil || !empty.Get().IsZero() {
+		t.Errorf("Should encode nothing before any value: %v, %v", empty.Get(), err)
+	}
+}
+
+func TestMissingValueEncoder_Record(t *testing.T) {
+	value, _ := NewScalarSensor(64, 4, 0, 120)
+	color, _ := NewCategorySensor(12, 4, "red", "green", "blue")
+	m, err := NewMultiEncoder(
+		Field{"color", color},
+		Field{"value", NewMissingValueEncoder(value, MissingEmpty)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Encode(map[string]interface{}{"color": "red", "value": nil}); err != nil {
+		t.Fatal(err)
+	}
+	decoded := m.DecodeRecord(m.Get())
+	if decoded["color"] != "red" || decoded["value"] != nil {
+		t.Errorf("Bad decoded record: %v", decoded)
+	}
+	// An absent key is a missing value, too.
+	withNil := m.Get().Clone()
+	if err := m.Encode(map[string]interface{}{"color": "red"}); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Get().Equals(*withNil) {
+		t.Errorf("An absent value should encode like nil: %v vs %v", m.Get(), withNil)
+	}
+	// Fields without a MissingValueEncoder must still be present.
+	if err := m.Encode(map[string]interface{}{"value": 10}); err == nil {
+		t.Error("Should fail without the color field.")
+	}
+}
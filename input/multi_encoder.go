@@ -0,0 +1,139 @@
+package input
+
+import "bytes"
+import "fmt"
+import "github.com/dukejeffrie/htm/data"
+
+// A named field of a record, and the encoder for its values.
+type Field struct {
+	Name    string
+	Encoder Encoder
+}
+
+// Encodes a record (a dictionary of field name to value) by routing each field to
+// its own encoder and placing the results side by side in a single bitset.
+type MultiEncoder struct {
+	fields []Field
+	layout *data.Layout
+
+	// The last encoded record, both as bits and as raw input.
+	value *data.Bitset
+	input map[string]interface{}
+}
+
+// Creates a new multi-field encoder. Fields are laid out in the given order.
+func NewMultiEncoder(fields ...Field) (*MultiEncoder, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("MultiEncoder needs at least one field.")
+	}
+	layout := data.NewLayout()
+	for _, f := range fields {
+		if f.Encoder == nil {
+			return nil, fmt.Errorf("Field \"%s\" has no encoder.", f.Name)
+		}
+		if err := layout.Add(f.Name, f.Encoder.Width()); err != nil {
+			return nil, err
+		}
+	}
+	result := &MultiEncoder{
+		fields: fields,
+		layout: layout,
+		value:  data.NewBitset(layout.Len()),
+	}
+	return result, nil
+}
+
+// Encodes a record, which must be a map[string]interface{}.
+func (m *MultiEncoder) Encode(value interface{}) error {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return m.EncodeRecord(value)
+	default:
+		m.input = nil
+		m.value.Reset()
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+// Encodes each field of the record with its encoder. Every configured field must
+// be present in the record, unless its encoder is a *MissingValueEncoder: then an
+// absent field is a nil value, handled by the encoder's policy. Other keys in the
+// record are ignored.
+func (m *MultiEncoder) EncodeRecord(record map[string]interface{}) error {
+	m.input = record
+	m.value.Reset()
+	for _, f := range m.fields {
+		v, ok := record[f.Name]
+		if _, handles := f.Encoder.(*MissingValueEncoder); !ok && !handles {
+			return fmt.Errorf("Missing field \"%s\" in record.", f.Name)
+		}
+		if err := f.Encoder.Encode(v); err != nil {
+			return fmt.Errorf("Cannot encode field \"%s\": %v", f.Name, err)
+		}
+		offset, _, _ := m.layout.Field(f.Name)
+		m.value.SetFromBitsetAt(f.Encoder.Get(), offset)
+	}
+	return nil
+}
+
+// Decodes a bitset into a map[string]interface{} with one value per field.
+func (m MultiEncoder) Decode(bits data.Bitset) interface{} {
+	return m.DecodeRecord(bits)
+}
+
+// Decodes each field of the bitset with its encoder.
+func (m MultiEncoder) DecodeRecord(bits data.Bitset) map[string]interface{} {
+	parts := m.layout.Split(bits)
+	result := make(map[string]interface{}, len(m.fields))
+	for i, f := range m.fields {
+		result[f.Name] = f.Encoder.Decode(*parts[i])
+	}
+	return result
+}
+
+func (m MultiEncoder) Get() data.Bitset {
+	return *m.value
+}
+
+func (m MultiEncoder) Raw() interface{} {
+	return m.input
+}
+
+func (m MultiEncoder) Width() int {
+	return m.layout.Len()
+}
+
+// The offsets of each field in the encoded bitset.
+func (m MultiEncoder) Layout() data.Layout {
+	return *m.layout
+}
+
+// The fields of this encoder, in layout order. The returned slice must not be
+// modified.
+func (m MultiEncoder) Fields() []Field {
+	return m.fields
+}
+
+// Returns the encoder for the named field, with the ok idiom.
+func (m MultiEncoder) Encoder(name string) (Encoder, bool) {
+	for _, f := range m.fields {
+		if f.Name == name {
+			return f.Encoder, true
+		}
+	}
+	return nil, false
+}
+
+func (m MultiEncoder) Description() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "multi(n=%d", m.Width())
+	for _, f := range m.fields {
+		fmt.Fprintf(&buf, ", %s=%s", f.Name, f.Encoder.Description())
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func (m MultiEncoder) String() string {
+	return fmt.Sprint(">>", m.input, "=", *m.value)
+}
@@ -0,0 +1,71 @@
+package input
+
+import "testing"
+
+func newTestMultiEncoder(t *testing.T) *MultiEncoder {
+	value, err := NewScalarSensor(64, 4, 0, 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	color, err := NewCategorySensor(12, 4, "red", "green", "blue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewMultiEncoder(Field{"color", color}, Field{"value", value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMultiEncoder(t *testing.T) {
+	m := newTestMultiEncoder(t)
+	if m.Width() != 76 {
+		t.Errorf("Width should be %d, but is %d", 76, m.Width())
+	}
+	if offset, length, ok := m.Layout().Field("value"); !ok || offset != 12 || length != 64 {
+		t.Errorf("Bad layout for value: %v", m.Layout())
+	}
+	record := map[string]interface{}{"color": "green", "value": 60.0, "other": 1}
+	if err := m.Encode(record); err != nil {
+		t.Fatal(err)
+	}
+	// green is bits [4, 8); 60.0 is bucket 30 in value, so [42, 46).
+	expected := "[0004,0005,0006,0007,0042,0043,0044,0045]"
+	if m.Get().String() != expected {
+		t.Errorf("Encode failed. Expected: %s, but got: %v", expected, m.Get())
+	}
+	decoded := m.DecodeRecord(m.Get())
+	if decoded["color"] != "green" {
+		t.Errorf("Bad decoded color: %v", decoded)
+	}
+	if v := decoded["value"].(float64); v < 60.0 || v >= 62.0 {
+		t.Errorf("Bad decoded value: %v", decoded)
+	}
+	if _, ok := decoded["other"]; ok {
+		t.Errorf("Should not decode unknown field: %v", decoded)
+	}
+	if e, ok := m.Encoder("color"); !ok || e.NumBuckets() != 3 {
+		t.Errorf("Bad encoder for color: %v", e)
+	}
+}
+
+func TestMultiEncoder_Errors(t *testing.T) {
+	m := newTestMultiEncoder(t)
+	if err := m.Encode(map[string]interface{}{"color": "red"}); err == nil {
+		t.Error("Should fail with a missing field.")
+	}
+	if err := m.Encode(map[string]interface{}{"color": "pink", "value": 1}); err == nil {
+		t.Error("Should fail with a bad field value.")
+	}
+	if err := m.Encode(42); err == nil {
+		t.Error("Should fail to encode a non-record.")
+	}
+	s, _ := NewScalarSensor(10, 2, 0, 100)
+	if _, err := NewMultiEncoder(Field{"a", s}, Field{"a", s}); err == nil {
+		t.Error("Should fail with duplicate fields.")
+	}
+	if _, err := NewMultiEncoder(); err == nil {
+		t.Error("Should fail without fields.")
+	}
+}
@@ -0,0 +1,111 @@
+package input
+
+import "fmt"
+import "github.com/dukejeffrie/htm/data"
+
+// Passes precomputed sparse patterns through unchanged, after checking their
+// length and sparsity. Patterns can be given as a list of indices, as a string of
+// '0' and '1' characters, or as a data.Bitset.
+type PassThroughSensor struct {
+	*Sensor
+	// Patterns must have between MinBits and W bits set. MinBits defaults to 1;
+	// wrap the sensor in a MissingValueEncoder to handle gaps.
+	MinBits int
+}
+
+// Creates a pass-through sensor for patterns of n bits, with at most w set.
+func NewPassThroughSensor(n, w int) (*PassThroughSensor, error) {
+	if w <= 0 || w > n {
+		return nil, fmt.Errorf("Need 0 < w (%d) <= n (%d).", w, n)
+	}
+	result := &PassThroughSensor{
+		Sensor:  NewSensor(n, w),
+		MinBits: 1,
+	}
+	return result, nil
+}
+
+func (s *PassThroughSensor) Encode(value interface{}) error {
+	s.input = value
+	s.value.Reset()
+	var err error
+	switch value := value.(type) {
+	case []int:
+		err = s.encodeIndices(value)
+	case string:
+		err = s.encodeString(value)
+	case data.Bitset:
+		err = s.encodeBitset(value)
+	case *data.Bitset:
+		err = s.encodeBitset(*value)
+	default:
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+	if err == nil {
+		err = s.checkSparsity()
+	}
+	if err != nil {
+		s.value.Reset()
+	}
+	return err
+}
+
+func (s *PassThroughSensor) encodeIndices(indices []int) error {
+	for _, i := range indices {
+		if i < 0 || i >= s.N {
+			return fmt.Errorf("Index %d out of range [0, %d).", i, s.N)
+		}
+	}
+	s.value.Set(indices...)
+	return nil
+}
+
+func (s *PassThroughSensor) encodeString(bits string) error {
+	if len(bits) != s.N {
+		return fmt.Errorf("Pattern \"%s\" should have %d bits, but has %d.", bits, s.N, len(bits))
+	}
+	for i, c := range bits {
+		switch c {
+		case '1':
+			s.value.Set(i)
+		case '0':
+		default:
+			return fmt.Errorf("Bad character '%c' at %d in pattern \"%s\".", c, i, bits)
+		}
+	}
+	return nil
+}
+
+func (s *PassThroughSensor) encodeBitset(bits data.Bitset) error {
+	if bits.Len() != s.N {
+		return fmt.Errorf("Bitset should have %d bits, but has %d.", s.N, bits.Len())
+	}
+	s.value.ResetTo(bits)
+	return nil
+}
+
+func (s PassThroughSensor) checkSparsity() error {
+	if set := s.value.NumSetBits(); set < s.MinBits || set > s.W {
+		return fmt.Errorf("Pattern should have between %d and %d bits set, but has %d.",
+			s.MinBits, s.W, set)
+	}
+	return nil
+}
+
+// Decodes the bits into the list of indices that are set.
+func (s PassThroughSensor) Decode(bits data.Bitset) interface{} {
+	return bits.Indices()
+}
+
+// Pass-through patterns have no buckets.
+func (s PassThroughSensor) Bucket(value interface{}) (int, error) {
+	return 0, fmt.Errorf("Pass-through values have no buckets.")
+}
+
+func (s PassThroughSensor) NumBuckets() int {
+	return 0
+}
+
+func (s PassThroughSensor) Description() string {
+	return fmt.Sprintf("pass-through(n=%d, w=%d..%d)", s.N, s.MinBits, s.W)
+}
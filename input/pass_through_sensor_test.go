@@ -0,0 +1,62 @@
+package input
+
+import "testing"
+import "github.com/dukejeffrie/htm/data"
+
+func TestPassThroughSensor(t *testing.T) {
+	s, err := NewPassThroughSensor(8, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := data.NewBitset(8).Set(1, 3, 6)
+	for _, v := range []interface{}{[]int{1, 3, 6}, "01010010", *expected, expected} {
+		if err := s.Encode(v); err != nil {
+			t.Errorf("Could not encode %v: %v", v, err)
+			continue
+		}
+		if !s.Get().Equals(*expected) {
+			t.Errorf("Encode(%v) failed. Expected: %v, but got: %v", v, expected, s.Get())
+		}
+	}
+	indices := s.Decode(*expected).([]int)
+	if len(indices) != 3 || indices[0] != 1 || indices[1] != 3 || indices[2] != 6 {
+		t.Errorf("Bad decoded indices: %v", indices)
+	}
+	if err := s.Encode([]int{1, 8}); err == nil {
+		t.Error("Should fail with an index out of range.")
+	}
+	if !s.Get().IsZero() {
+		t.Errorf("Failed encodings should set no bits: %v", s.Get())
+	}
+	if err := s.Encode("0101"); err == nil {
+		t.Error("Should fail with a short string.")
+	}
+	if err := s.Encode("0101001x"); err == nil {
+		t.Error("Should fail with a bad character.")
+	}
+	if err := s.Encode("11110000"); err == nil {
+		t.Error("Should fail when too dense.")
+	}
+	if err := s.Encode([]int{}); err == nil {
+		t.Error("Should fail when empty.")
+	}
+	if err := s.Encode(*data.NewBitset(9).Set(1)); err == nil {
+		t.Error("Should fail with the wrong length.")
+	}
+}
+
+func TestPassThroughSensor_Record(t *testing.T) {
+	features, _ := NewPassThroughSensor(16, 4)
+	color, _ := NewCategorySensor(12, 4, "red", "green", "blue")
+	m, err := NewMultiEncoder(Field{"color", color}, Field{"features", features})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Encode(map[string]interface{}{"color": "blue", "features": []int{0, 15}}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "[0008,0009,0010,0011,0012,0027]"
+	if m.Get().String() != expected {
+		t.Errorf("Encode failed. Expected: %s, but got: %v", expected, m.Get())
+	}
+}
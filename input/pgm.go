@@ -0,0 +1,147 @@
+package input
+
+import "bufio"
+import "fmt"
+import "image"
+import "image/color"
+import "io"
+import "strconv"
+
+// The standard library has no decoder for PGM (netpbm grayscale) images, so this
+// one is registered with the image package for both the binary ("P5") and the
+// plain text ("P2") forms.
+func init() {
+	image.RegisterFormat("pgm", "P5", DecodePGM, DecodePGMConfig)
+	image.RegisterFormat("pgm", "P2", DecodePGM, DecodePGMConfig)
+}
+
+// Largest number of pixels in a PGM image, so that a bad header cannot make us
+// allocate arbitrarily large images.
+const maxPGMPixels = 1 << 26
+
+type pgmHeader struct {
+	magic         string
+	width, height int
+	maxval        int
+}
+
+// Reads the next whitespace-separated token, skipping comments. Consumes the single
+// whitespace character after the token.
+func readPGMToken(r *bufio.Reader) (string, error) {
+	token := make([]byte, 0, 8)
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(token) > 0 {
+				return string(token), nil
+			}
+			return "", err
+		}
+		switch {
+		case c == '#' && len(token) == 0:
+			if _, err := r.ReadString('\n'); err != nil {
+				return "", err
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if len(token) > 0 {
+				return string(token), nil
+			}
+		default:
+			token = append(token, c)
+		}
+	}
+}
+
+func readPGMInt(r *bufio.Reader, name string) (int, error) {
+	token, err := readPGMToken(r)
+	if err != nil {
+		return 0, fmt.Errorf("pgm: cannot read %s: %v", name, err)
+	}
+	v, err := strconv.Atoi(token)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("pgm: bad %s \"%s\"", name, token)
+	}
+	return v, nil
+}
+
+func readPGMHeader(r *bufio.Reader) (h pgmHeader, err error) {
+	if h.magic, err = readPGMToken(r); err != nil {
+		return
+	}
+	if h.magic != "P5" && h.magic != "P2" {
+		err = fmt.Errorf("pgm: bad magic number \"%s\"", h.magic)
+		return
+	}
+	if h.width, err = readPGMInt(r, "width"); err != nil {
+		return
+	}
+	if h.height, err = readPGMInt(r, "height"); err != nil {
+		return
+	}
+	// Width and height are positive, so the division cannot overflow.
+	if h.width > maxPGMPixels/h.height {
+		err = fmt.Errorf("pgm: image of %dx%d is too large (max %d pixels)",
+			h.width, h.height, maxPGMPixels)
+		return
+	}
+	if h.maxval, err = readPGMInt(r, "maxval"); err != nil {
+		return
+	}
+	if h.maxval > 65535 {
+		err = fmt.Errorf("pgm: maxval %d is too large", h.maxval)
+	}
+	return
+}
+
+// Decodes a PGM image into an *image.Gray. Images with more than 8 bits per pixel
+// are scaled down to 8 bits.
+func DecodePGM(reader io.Reader) (image.Image, error) {
+	r := bufio.NewReader(reader)
+	h, err := readPGMHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	result := image.NewGray(image.Rect(0, 0, h.width, h.height))
+	for i := range result.Pix {
+		var v int
+		switch {
+		case h.magic == "P2":
+			var token string
+			if token, err = readPGMToken(r); err == nil {
+				v, err = strconv.Atoi(token)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("pgm: bad pixel %d: %v", i, err)
+			}
+		case h.maxval < 256:
+			var b byte
+			if b, err = r.ReadByte(); err != nil {
+				return nil, fmt.Errorf("pgm: bad pixel %d: %v", i, err)
+			}
+			v = int(b)
+		default:
+			var hi, lo byte
+			if hi, err = r.ReadByte(); err == nil {
+				lo, err = r.ReadByte()
+			}
+			if err != nil {
+				return nil, fmt.Errorf("pgm: bad pixel %d: %v", i, err)
+			}
+			v = int(hi)<<8 | int(lo)
+		}
+		if v < 0 || v > h.maxval {
+			return nil, fmt.Errorf("pgm: pixel %d (%d) is out of range [0, %d]", i, v, h.maxval)
+		}
+		result.Pix[i] = uint8(v * 255 / h.maxval)
+	}
+	return result, nil
+}
+
+// Decodes the size of a PGM image.
+func DecodePGMConfig(reader io.Reader) (image.Config, error) {
+	h, err := readPGMHeader(bufio.NewReader(reader))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.GrayModel, Width: h.width, Height: h.height}, nil
+}
@@ -0,0 +1,51 @@
+package input
+
+import "bytes"
+import "image"
+import "testing"
+
+func TestDecodePGM(t *testing.T) {
+	inputs := map[string][]byte{
+		"binary": append([]byte("P5\n# a comment\n3 2\n255\n"), 0, 128, 255, 255, 128, 0),
+		"text":   []byte("P2\n3 2\n# a comment\n15\n0 8 15\n15 8 0\n"),
+		"16-bit": append([]byte("P5 3 2 65535\n"), 0, 0, 128, 128, 255, 255, 255, 255, 128, 128, 0, 0),
+	}
+	expected := []uint8{0, 128, 255, 255, 128, 0}
+	for name, input := range inputs {
+		img, format, err := image.Decode(bytes.NewReader(input))
+		if err != nil {
+			t.Errorf("Could not decode %s image: %v", name, err)
+			continue
+		}
+		if format != "pgm" {
+			t.Errorf("Bad format for %s image: %s", name, format)
+		}
+		gray := img.(*image.Gray)
+		if gray.Rect.Dx() != 3 || gray.Rect.Dy() != 2 {
+			t.Errorf("Bad size for %s image: %v", name, gray.Rect)
+		}
+		for i, v := range expected {
+			if diff := int(gray.Pix[i]) - int(v); diff < -8 || diff > 8 {
+				t.Errorf("Bad pixel %d in %s image. Expected: %d, but got: %d", i, name, v, gray.Pix[i])
+			}
+		}
+	}
+}
+
+func TestDecodePGM_Errors(t *testing.T) {
+	for _, input := range []string{"P6\n1 1\n255\n\x00", "P5\n0 1\n255\n", "P5\n2 1\n255\n\x00",
+		"P2\n1 1\n15\n16\n", "P5\n-1 1\n255\n\x00", "P5\n1 -1\n255\n\x00"} {
+		if _, err := DecodePGM(bytes.NewReader([]byte(input))); err == nil {
+			t.Errorf("Should fail to decode %q.", input)
+		}
+	}
+	// Huge sizes must fail before allocating, even if they overflow.
+	for _, input := range []string{"P5\n100000 100000\n255\n", "P5\n9223372036854775807 2\n255\n"} {
+		if _, err := DecodePGM(bytes.NewReader([]byte(input))); err == nil {
+			t.Errorf("Should fail to decode %q.", input)
+		}
+		if _, err := DecodePGMConfig(bytes.NewReader([]byte(input))); err == nil {
+			t.Errorf("Should fail to decode the config of %q.", input)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package input
+
+import "fmt"
+import "math/rand"
+import "github.com/dukejeffrie/htm/data"
+
+// The category that values are decoded to when they match no known category, see
+// RandomCategorySensor.
+const UnknownCategory = "<unknown>"
+
+// A category sensor where each category is a random set of W bits out of N, so
+// every bit carries about the same information no matter how many categories
+// there are. Categories can be added after construction, up to Capacity; values
+// that cannot be added are encoded with the code reserved for UnknownCategory.
+//
+// Codes are drawn from a random source seeded at construction, in the order the
+// categories are added, so the same seed and categories give the same encodings.
+type RandomCategorySensor struct {
+	*Sensor
+	// The maximum number of categories, not counting UnknownCategory.
+	Capacity int
+	// Whether Encode() adds categories it has not seen before.
+	Grow bool
+
+	ids     map[string]int
+	reverse []string
+	codes   [][]int
+	rng     *rand.Rand
+}
+
+// Creates a new random category sensor of n bits with w bits per category. The
+// sensor grows on demand; set Grow to false to encode new values as unknown.
+func NewRandomCategorySensor(n, w, capacity int, seed int64, categories ...string) (*RandomCategorySensor, error) {
+	if w <= 0 || w >= n {
+		return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", w, n)
+	}
+	if capacity < len(categories) {
+		return nil, fmt.Errorf("Cannot fit %d categories into capacity %d.",
+			len(categories), capacity)
+	}
+	result := &RandomCategorySensor{
+		Sensor:   NewSensor(n, w),
+		Capacity: capacity,
+		Grow:     true,
+		ids:      make(map[string]int, len(categories)+1),
+		reverse:  make([]string, 0, len(categories)+1),
+		codes:    make([][]int, 0, len(categories)+1),
+		rng:      rand.New(rand.NewSource(seed)),
+	}
+	result.add(UnknownCategory)
+	for _, c := range categories {
+		if _, err := result.Add(c); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (s *RandomCategorySensor) add(category string) int {
+	id := len(s.reverse)
+	s.ids[category] = id
+	s.reverse = append(s.reverse, category)
+	s.codes = append(s.codes, s.rng.Perm(s.N)[:s.W])
+	return id
+}
+
+// Adds a category if it is new, and returns its bucket.
+func (s *RandomCategorySensor) Add(category string) (int, error) {
+	if id, ok := s.ids[category]; ok {
+		return id, nil
+	}
+	if len(s.reverse) > s.Capacity {
+		return 0, fmt.Errorf("Cannot add category \"%s\": capacity (%d) reached.",
+			category, s.Capacity)
+	}
+	return s.add(category), nil
+}
+
+// The known categories, starting with UnknownCategory. The returned slice must not
+// be modified.
+func (s RandomCategorySensor) Categories() []string {
+	return s.reverse
+}
+
+func (s RandomCategorySensor) String() string {
+	return fmt.Sprint(*s.Sensor, "[", len(s.reverse)-1, "/", s.Capacity, "]")
+}
+
+func (s *RandomCategorySensor) Encode(value interface{}) error {
+	s.input = value
+	s.value.Reset()
+	switch value := value.(type) {
+	case string:
+		return s.EncodeString(value)
+	default:
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+// Encodes a category, adding it if Grow is set and there is room. Otherwise,
+// unseen categories are encoded as UnknownCategory.
+func (s *RandomCategorySensor) EncodeString(cat string) error {
+	id, ok := s.ids[cat]
+	if !ok && s.Grow && len(s.reverse) <= s.Capacity {
+		id = s.add(cat)
+	}
+	s.value.Set(s.codes[id]...)
+	return nil
+}
+
+// Returns the bucket that Encode() would use: that of a known category, the bucket
+// an unseen category would be added with if Grow is set and there is room, or else
+// the bucket of UnknownCategory (0). Does not add the category.
+func (s RandomCategorySensor) Bucket(value interface{}) (int, error) {
+	cat, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+	if id, ok := s.ids[cat]; ok {
+		return id, nil
+	}
+	if s.Grow && len(s.reverse) <= s.Capacity {
+		return len(s.reverse), nil
+	}
+	return 0, nil
+}
+
+// The number of known categories, including UnknownCategory.
+func (s RandomCategorySensor) NumBuckets() int {
+	return len(s.reverse)
+}
+
+// Decodes the bits into the category with the best overlap.
+func (s RandomCategorySensor) Decode(bits data.Bitset) interface{} {
+	return s.reverse[bestBucket(codeOverlaps(bits, s.codes))]
+}
+
+func (s RandomCategorySensor) DecodeCandidates(bits data.Bitset, k int) []Candidate {
+	return rankCandidates(codeOverlaps(bits, s.codes), k, func(bucket int) interface{} {
+		return s.reverse[bucket]
+	})
+}
+
+func (s RandomCategorySensor) Description() string {
+	return fmt.Sprintf("random category(n=%d, w=%d, categories=%d, capacity=%d)",
+		s.N, s.W, len(s.reverse)-1, s.Capacity)
+}
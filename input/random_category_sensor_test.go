@@ -0,0 +1,85 @@
+package input
+
+import "testing"
+
+func TestRandomCategorySensor(t *testing.T) {
+	s, err := NewRandomCategorySensor(256, 8, 3, 42, "red", "green")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Encode("red"); err != nil {
+		t.Fatal(err)
+	}
+	red := s.Get().Clone()
+	if red.NumSetBits() != s.W {
+		t.Errorf("Should have %d bits set, but has %d: %v", s.W, red.NumSetBits(), red)
+	}
+	if v := s.Decode(*red); v != "red" {
+		t.Errorf("Decode failed. Expected: %v, but got: %v", "red", v)
+	}
+	// Growing up to capacity. Bucket() agrees with the bucket blue is added with.
+	expected, _ := s.Bucket("blue")
+	if s.NumBuckets() != 3 {
+		t.Errorf("Bucket() should not add categories: %v", s.Categories())
+	}
+	s.Encode("blue")
+	if b, _ := s.Bucket("blue"); b != expected || b != 3 {
+		t.Errorf("Bucket(blue) should be %d, but was %d before and %d after Encode().", 3, expected, b)
+	}
+	blue := s.Get().Clone()
+	if s.NumBuckets() != 4 {
+		t.Errorf("Should have %d buckets, but has %d", 4, s.NumBuckets())
+	}
+	if blue.Overlap(*red) > 2 {
+		t.Errorf("Random codes overlap too much: %v vs %v", blue, red)
+	}
+	// Capacity reached, so pink is unknown.
+	s.Encode("pink")
+	if v := s.Decode(s.Get()); v != UnknownCategory {
+		t.Errorf("Decode failed. Expected: %v, but got: %v", UnknownCategory, v)
+	}
+	if _, err := s.Add("pink"); err == nil {
+		t.Error("Should fail to add beyond capacity.")
+	}
+	if b, _ := s.Bucket("pink"); b != 0 {
+		t.Errorf("Unknown categories should be bucket 0, but got %d", b)
+	}
+	// Decode a noisy version of blue.
+	noisy := blue.Clone()
+	noisy.Unset(noisy.Indices()[:3]...)
+	noisy.Or(*red.Clone().Unset(red.Indices()[:6]...))
+	if v := s.Decode(*noisy); v != "blue" {
+		t.Errorf("Decode(%v) failed. Expected: %v, but got: %v", noisy, "blue", v)
+	}
+
+	// Same seed and order give the same codes.
+	other, _ := NewRandomCategorySensor(256, 8, 3, 42)
+	other.Encode("red")
+	if !other.Get().Equals(*red) {
+		t.Errorf("Codes are not reproducible: %v vs %v", other.Get(), red)
+	}
+}
+
+func TestRandomCategorySensor_NoGrow(t *testing.T) {
+	s, err := NewRandomCategorySensor(64, 4, 10, 1, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Grow = false
+	s.Encode("B")
+	if v := s.Decode(s.Get()); v != UnknownCategory {
+		t.Errorf("Decode failed. Expected: %v, but got: %v", UnknownCategory, v)
+	}
+	if len(s.Categories()) != 2 {
+		t.Errorf("Should not have added B: %v", s.Categories())
+	}
+	if b, _ := s.Bucket("B"); b != 0 {
+		t.Errorf("Unknown categories should be bucket 0 without Grow, but got %d", b)
+	}
+	if _, err := NewRandomCategorySensor(64, 4, 1, 1, "A", "B"); err == nil {
+		t.Error("Should fail with more categories than capacity.")
+	}
+	if err := s.Encode(1); err == nil {
+		t.Error("Should fail to encode a non-string.")
+	}
+}
@@ -0,0 +1,170 @@
+package input
+
+import "fmt"
+import "math"
+import "math/rand"
+import "github.com/dukejeffrie/htm/data"
+
+// Default maximum number of buckets for a RandomDistributedScalarSensor.
+const DefaultMaxBuckets = 1000
+
+// A scalar sensor where each bucket maps to W pseudo-random bits out of N. Adjacent
+// buckets share W-1 bits, so nearby values still have overlapping encodings, but
+// there is no fixed [min, max) range: buckets are created on demand around Offset.
+//
+// Buckets above and below Offset are generated in sequence by two random sources
+// derived from the seed, so the encodings only depend on the seed and parameters,
+// not on the order in which values are encoded.
+type RandomDistributedScalarSensor struct {
+	*Sensor
+	// The width of each bucket.
+	Resolution float64
+	// The value at the center of bucket 0.
+	Offset float64
+	// The maximum number of buckets, half below and half above Offset. Values
+	// beyond the outermost buckets are encoded as the outermost buckets.
+	MaxBuckets int
+
+	// Bit indices of each bucket created so far. Within a bucket, indices are kept
+	// in the order they were added, so that the next bucket drops the oldest one.
+	buckets              map[int][]int
+	minBucket, maxBucket int
+	upRand, downRand     *rand.Rand
+}
+
+// Creates a new random distributed scalar sensor of n bits with w bits per bucket.
+func NewRandomDistributedScalarSensor(n, w int, resolution, offset float64, seed int64) (*RandomDistributedScalarSensor, error) {
+	if w <= 0 || w >= n {
+		return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", w, n)
+	}
+	if resolution <= 0 {
+		return nil, fmt.Errorf("Resolution must be positive, but is %f.", resolution)
+	}
+	result := &RandomDistributedScalarSensor{
+		Sensor:     NewSensor(n, w),
+		Resolution: resolution,
+		Offset:     offset,
+		MaxBuckets: DefaultMaxBuckets,
+		buckets:    make(map[int][]int),
+		upRand:     rand.New(rand.NewSource(seed + 1)),
+		downRand:   rand.New(rand.NewSource(^seed)),
+	}
+	// Bucket 0 uses w distinct random bits.
+	result.buckets[0] = rand.New(rand.NewSource(seed)).Perm(n)[:w]
+	return result, nil
+}
+
+func (s RandomDistributedScalarSensor) String() string {
+	return fmt.Sprint(*s.Sensor, "[", s.Offset, "+-", s.Resolution, "*",
+		s.MaxBuckets/2, "]")
+}
+
+func (s *RandomDistributedScalarSensor) Encode(value interface{}) error {
+	s.input = value
+	s.value.Reset()
+	switch value := value.(type) {
+	case int:
+		return s.EncodeFloat(float64(value))
+	case float64:
+		return s.EncodeFloat(value)
+	default:
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+func (s *RandomDistributedScalarSensor) EncodeFloat(value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("Cannot encode %f.", value)
+	}
+	s.value.Set(s.bucketBits(s.bucketFor(value))...)
+	return nil
+}
+
+// Returns the bucket index relative to Offset, clipped to MaxBuckets.
+func (s RandomDistributedScalarSensor) bucketFor(value float64) int {
+	half := s.MaxBuckets / 2
+	b := math.Floor((value-s.Offset)/s.Resolution + 0.5)
+	if b < float64(-half) {
+		return -half
+	} else if b > float64(s.MaxBuckets-half-1) {
+		return s.MaxBuckets - half - 1
+	}
+	return int(b)
+}
+
+// Returns the bits for a bucket, creating it and all buckets between it and the
+// ones that already exist.
+func (s *RandomDistributedScalarSensor) bucketBits(b int) []int {
+	for s.maxBucket < b {
+		s.buckets[s.maxBucket+1] = s.neighbor(s.buckets[s.maxBucket], s.upRand)
+		s.maxBucket++
+	}
+	for s.minBucket > b {
+		s.buckets[s.minBucket-1] = s.neighbor(s.buckets[s.minBucket], s.downRand)
+		s.minBucket--
+	}
+	return s.buckets[b]
+}
+
+// Creates a bucket that shares all but one bit with the given bucket.
+func (s RandomDistributedScalarSensor) neighbor(bits []int, rng *rand.Rand) []int {
+	result := make([]int, len(bits))
+	copy(result, bits[1:])
+	existing := make(map[int]bool, len(bits))
+	for _, v := range bits {
+		existing[v] = true
+	}
+	next := rng.Intn(s.N)
+	for existing[next] {
+		next = rng.Intn(s.N)
+	}
+	result[len(result)-1] = next
+	return result
+}
+
+func (s RandomDistributedScalarSensor) Bucket(value interface{}) (int, error) {
+	switch value := value.(type) {
+	case int:
+		return s.bucketFor(float64(value)) + s.MaxBuckets/2, nil
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, fmt.Errorf("Cannot encode %f.", value)
+		}
+		return s.bucketFor(value) + s.MaxBuckets/2, nil
+	default:
+		return 0, fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+func (s RandomDistributedScalarSensor) NumBuckets() int {
+	return s.MaxBuckets
+}
+
+// Decodes the bits into the center value of the existing bucket with the best
+// overlap.
+func (s RandomDistributedScalarSensor) Decode(bits data.Bitset) interface{} {
+	return s.bucketValue(bestBucket(s.bucketOverlaps(bits)))
+}
+
+// Ranks the existing buckets, see Decode().
+func (s RandomDistributedScalarSensor) DecodeCandidates(bits data.Bitset, k int) []Candidate {
+	return rankCandidates(s.bucketOverlaps(bits), k, s.bucketValue)
+}
+
+// Returns the overlap with each existing bucket, starting from minBucket.
+func (s RandomDistributedScalarSensor) bucketOverlaps(bits data.Bitset) []int {
+	codes := make([][]int, 0, s.maxBucket-s.minBucket+1)
+	for b := s.minBucket; b <= s.maxBucket; b++ {
+		codes = append(codes, s.buckets[b])
+	}
+	return codeOverlaps(bits, codes)
+}
+
+func (s RandomDistributedScalarSensor) bucketValue(index int) interface{} {
+	return s.Offset + float64(s.minBucket+index)*s.Resolution
+}
+
+func (s RandomDistributedScalarSensor) Description() string {
+	return fmt.Sprintf("random scalar(n=%d, w=%d, offset=%v, resolution=%v, buckets=%d)",
+		s.N, s.W, s.Offset, s.Resolution, s.MaxBuckets)
+}
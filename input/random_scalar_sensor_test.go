@@ -0,0 +1,82 @@
+package input
+
+import "github.com/dukejeffrie/htm/data"
+import "testing"
+
+func TestRandomDistributedScalarSensor(t *testing.T) {
+	s, err := NewRandomDistributedScalarSensor(400, 21, 1.0, 0.0, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(v float64) *data.Bitset {
+		if err := s.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+		if s.Get().NumSetBits() != s.W {
+			t.Errorf("Encoding of %f should have %d bits: %v", v, s.W, s.Get())
+		}
+		return s.Get().Clone()
+	}
+	zero := encode(0.0)
+	if !encode(0.4).Equals(*zero) {
+		t.Error("0.0 and 0.4 should fall in the same bucket.")
+	}
+	one := encode(1.0)
+	if zero.Overlap(*one) != s.W-1 {
+		t.Errorf("Adjacent buckets should overlap on %d bits: %v, %v", s.W-1, zero, one)
+	}
+	far := encode(100.0)
+	if zero.Overlap(*far) > 5 {
+		t.Errorf("Distant buckets should barely overlap: %v, %v", zero, far)
+	}
+	negative := encode(-100.0)
+	if v := s.Decode(*negative); v != -100.0 {
+		t.Errorf("Decode failed. Expected: %f, but got: %v", -100.0, v)
+	}
+	if v := s.Decode(*one); v != 1.0 {
+		t.Errorf("Decode failed. Expected: %f, but got: %v", 1.0, v)
+	}
+	// Values beyond MaxBuckets are clipped to the outermost bucket.
+	huge := encode(1e9)
+	if !huge.Equals(*encode(float64(s.MaxBuckets/2 - 1))) {
+		t.Error("Values past the last bucket should be clipped.")
+	}
+	if b, _ := s.Bucket(1e9); b != s.NumBuckets()-1 {
+		t.Errorf("Bucket should be %d, but is %d", s.NumBuckets()-1, b)
+	}
+	if err = s.Encode("x"); err == nil {
+		t.Error("Should fail to encode a string.")
+	}
+}
+
+func TestRandomDistributedScalarSensor_Reproducible(t *testing.T) {
+	a, _ := NewRandomDistributedScalarSensor(400, 21, 0.5, 10.0, 7)
+	b, _ := NewRandomDistributedScalarSensor(400, 21, 0.5, 10.0, 7)
+	// Encode in different orders; the same values must get the same bits.
+	a.Encode(20.0)
+	a.Encode(0.0)
+	b.Encode(0.0)
+	b.Encode(20.0)
+	for _, v := range []float64{0.0, 5.0, 10.0, 15.0, 20.0} {
+		a.Encode(v)
+		b.Encode(v)
+		if !a.Get().Equals(b.Get()) {
+			t.Errorf("Encodings of %f differ: %v != %v", v, a.Get(), b.Get())
+		}
+	}
+	c, _ := NewRandomDistributedScalarSensor(400, 21, 0.5, 10.0, 8)
+	c.Encode(10.0)
+	a.Encode(10.0)
+	if a.Get().Equals(c.Get()) {
+		t.Error("Different seeds should produce different encodings.")
+	}
+}
+
+func TestRandomDistributedScalarSensor_BadParameters(t *testing.T) {
+	if _, err := NewRandomDistributedScalarSensor(10, 10, 1.0, 0, 1); err == nil {
+		t.Error("Should fail with w >= n.")
+	}
+	if _, err := NewRandomDistributedScalarSensor(100, 10, 0, 0, 1); err == nil {
+		t.Error("Should fail with zero resolution.")
+	}
+}
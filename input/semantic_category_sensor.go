@@ -0,0 +1,202 @@
+package input
+
+import "fmt"
+import "math"
+import "math/rand"
+import "strings"
+import "github.com/dukejeffrie/htm/data"
+
+// Separates the levels of a category path, as in "shoes/running".
+const CategorySeparator = "/"
+
+// A category sensor where the overlap between categories reflects how similar they
+// are. Categories are paths in a hierarchy ("shoes/running"), and each level of
+// the path contributes an equal share of the W bits: "shoes/running" and
+// "shoes/trail" share W/2 bits, "shoes/running/road" and "shoes/running/trail"
+// share 2W/3. SetSimilarity() overrides the hierarchy for specific pairs.
+//
+// Every node of the hierarchy draws its bits from a random source seeded at
+// construction, in the order the nodes are first seen.
+type SemanticCategorySensor struct {
+	*Sensor
+
+	ids   map[string]int
+	names []string
+	codes [][]int
+	// W random bits for each node of the hierarchy.
+	nodes map[string][]int
+	rng   *rand.Rand
+}
+
+// Creates a new semantic category sensor of n bits with w bits per category.
+func NewSemanticCategorySensor(n, w int, seed int64, categories ...string) (*SemanticCategorySensor, error) {
+	if w <= 0 || w >= n {
+		return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", w, n)
+	}
+	result := &SemanticCategorySensor{
+		Sensor: NewSensor(n, w),
+		ids:    make(map[string]int, len(categories)),
+		nodes:  make(map[string][]int),
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+	for _, c := range categories {
+		if err := result.Add(c); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Adds a category, given as a path in the hierarchy. Adding a known category does
+// nothing.
+func (s *SemanticCategorySensor) Add(category string) error {
+	if _, ok := s.ids[category]; ok {
+		return nil
+	}
+	levels := strings.Split(category, CategorySeparator)
+	for _, l := range levels {
+		if l == "" {
+			return fmt.Errorf("Bad category \"%s\": empty level.", category)
+		}
+	}
+	share := s.W / len(levels)
+	code := make([]int, 0, s.W)
+	used := make(map[int]bool, s.W)
+	take := func(bits []int, count int) {
+		for _, b := range bits {
+			if len(code) == cap(code) || count == 0 {
+				return
+			}
+			if !used[b] {
+				used[b] = true
+				code = append(code, b)
+				count--
+			}
+		}
+	}
+	for i := 1; i < len(levels); i++ {
+		take(s.nodeBits(strings.Join(levels[:i], CategorySeparator)), share)
+	}
+	// The leaf gets the rest, and makes up for collisions between the levels.
+	take(s.nodeBits(category), s.W)
+	s.ids[category] = len(s.names)
+	s.names = append(s.names, category)
+	s.codes = append(s.codes, code)
+	return nil
+}
+
+func (s *SemanticCategorySensor) nodeBits(node string) []int {
+	bits, ok := s.nodes[node]
+	if !ok {
+		bits = s.rng.Perm(s.N)[:s.W]
+		s.nodes[node] = bits
+	}
+	return bits
+}
+
+// Makes b share about similarity*W bits with a, replacing the overlap that b got
+// from the hierarchy. Both categories must be known. Since b borrows bits from a,
+// set the similarities from general to specific categories.
+func (s *SemanticCategorySensor) SetSimilarity(a, b string, similarity float64) error {
+	ia, ok := s.ids[a]
+	if !ok {
+		return fmt.Errorf("Unknown category \"%s\".", a)
+	}
+	ib, ok := s.ids[b]
+	if !ok {
+		return fmt.Errorf("Unknown category \"%s\".", b)
+	}
+	if ia == ib {
+		return fmt.Errorf("Cannot set the similarity of \"%s\" to itself.", a)
+	}
+	if similarity < 0 || similarity > 1 {
+		return fmt.Errorf("Similarity must be in [0, 1], but is %f.", similarity)
+	}
+	shared := int(math.Floor(similarity*float64(s.W) + 0.5))
+	code := make([]int, 0, s.W)
+	used := make(map[int]bool, s.W)
+	for _, v := range s.codes[ia][:shared] {
+		used[v] = true
+		code = append(code, v)
+	}
+	// Fill up with b's own bits that a does not have.
+	inA := make(map[int]bool, s.W)
+	for _, v := range s.codes[ia] {
+		inA[v] = true
+	}
+	for _, bits := range [][]int{s.codes[ib], s.nodeBits(b)} {
+		for _, v := range bits {
+			if len(code) < s.W && !used[v] && !inA[v] {
+				used[v] = true
+				code = append(code, v)
+			}
+		}
+	}
+	s.codes[ib] = code
+	return nil
+}
+
+// The known categories, in the order they were added. The returned slice must not
+// be modified.
+func (s SemanticCategorySensor) Categories() []string {
+	return s.names
+}
+
+func (s *SemanticCategorySensor) Encode(value interface{}) error {
+	s.input = value
+	s.value.Reset()
+	switch value := value.(type) {
+	case string:
+		return s.EncodeString(value)
+	default:
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+func (s *SemanticCategorySensor) EncodeString(cat string) error {
+	id, err := s.Bucket(cat)
+	if err != nil {
+		return err
+	}
+	s.value.Set(s.codes[id]...)
+	return nil
+}
+
+func (s SemanticCategorySensor) Bucket(value interface{}) (int, error) {
+	cat, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+	id, ok := s.ids[cat]
+	if !ok {
+		return 0, fmt.Errorf("Unknown category \"%s\" in sensor: %v", cat, s)
+	}
+	return id, nil
+}
+
+func (s SemanticCategorySensor) NumBuckets() int {
+	return len(s.names)
+}
+
+// Decodes the bits into the category with the best overlap, or "" if no category
+// overlaps the bits.
+func (s SemanticCategorySensor) Decode(bits data.Bitset) interface{} {
+	best := s.DecodeCandidates(bits, 1)
+	if len(best) == 0 {
+		return ""
+	}
+	return best[0].Value
+}
+
+// Candidates are categories, best first. Ties are in the order the categories were
+// added.
+func (s SemanticCategorySensor) DecodeCandidates(bits data.Bitset, k int) []Candidate {
+	return rankCandidates(codeOverlaps(bits, s.codes), k, func(bucket int) interface{} {
+		return s.names[bucket]
+	})
+}
+
+func (s SemanticCategorySensor) Description() string {
+	return fmt.Sprintf("semantic category(n=%d, w=%d, categories=%d)",
+		s.N, s.W, len(s.names))
+}
@@ -0,0 +1,85 @@
+package input
+
+import "testing"
+import "github.com/dukejeffrie/htm/data"
+
+func TestSemanticCategorySensor(t *testing.T) {
+	s, err := NewSemanticCategorySensor(1024, 12, 42,
+		"shoes", "shoes/running", "shoes/trail", "shoes/running/road", "shoes/running/track", "hats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes := make(map[string]*data.Bitset)
+	for _, c := range s.Categories() {
+		if err := s.Encode(c); err != nil {
+			t.Fatal(err)
+		}
+		codes[c] = s.Get().Clone()
+		if v := s.Decode(s.Get()); v != c {
+			t.Errorf("Decode failed. Expected: %v, but got: %v", c, v)
+		}
+	}
+	tests := []struct {
+		a, b    string
+		overlap int
+	}{
+		{"shoes", "shoes/running", 6},
+		{"shoes/running", "shoes/trail", 6},
+		{"shoes/running/road", "shoes/running/track", 8},
+		{"shoes/running/road", "shoes/trail", 4},
+		{"shoes/running", "hats", 0},
+	}
+	for _, test := range tests {
+		// Allow for a random collision.
+		if o := codes[test.a].Overlap(*codes[test.b]); o < test.overlap || o > test.overlap+1 {
+			t.Errorf("%s and %s should overlap on %d bits, but overlap on %d.",
+				test.a, test.b, test.overlap, o)
+		}
+	}
+	closest := s.DecodeCandidates(*codes["shoes/running/road"], 3)
+	// The parent and the sibling both share 8 bits, and tie in the order they were added.
+	expected := []Candidate{
+		{Value: "shoes/running/road", Overlap: 12},
+		{Value: "shoes/running", Overlap: 8},
+		{Value: "shoes/running/track", Overlap: 8},
+	}
+	if len(closest) != len(expected) {
+		t.Fatalf("Bad closest categories: %v", closest)
+	}
+	for i, m := range expected {
+		if closest[i].Value != m.Value || closest[i].Overlap != m.Overlap {
+			t.Errorf("Bad closest category #%d. Expected: %v, but got: %v", i, m, closest[i])
+		}
+	}
+	if err := s.Encode("shoes/hiking"); err == nil {
+		t.Error("Should fail to encode an unknown category.")
+	}
+}
+
+func TestSemanticCategorySensor_Similarity(t *testing.T) {
+	s, err := NewSemanticCategorySensor(1024, 10, 7, "cat", "dog", "car")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSimilarity("cat", "dog", 0.6); err != nil {
+		t.Fatal(err)
+	}
+	s.Encode("cat")
+	cat := s.Get().Clone()
+	s.Encode("dog")
+	if o := s.Get().Overlap(*cat); o != 6 {
+		t.Errorf("cat and dog should overlap on %d bits, but overlap on %d.", 6, o)
+	}
+	if s.Get().NumSetBits() != s.W {
+		t.Errorf("Should have %d bits set, but has %d.", s.W, s.Get().NumSetBits())
+	}
+	if err := s.SetSimilarity("cat", "cow", 0.5); err == nil {
+		t.Error("Should fail with an unknown category.")
+	}
+	if err := s.SetSimilarity("cat", "car", 1.5); err == nil {
+		t.Error("Should fail with a similarity out of range.")
+	}
+	if err := s.Add("a//b"); err == nil {
+		t.Error("Should fail with an empty level.")
+	}
+}
@@ -6,6 +6,8 @@ import "math"
 import "fmt"
 import "github.com/dukejeffrie/htm/data"
 
+// Base type for all sensors, which holds the size and the last encoded value.
+// The concrete sensors below add Encode() and Decode(), see Encoder.
 type Sensor struct {
 	// The number of bits this sensor produces for each input
 	N int
@@ -33,11 +35,48 @@ func (s Sensor) Raw() interface{} {
 	return s.input
 }
 
+func (s Sensor) Width() int {
+	return s.N
+}
+
+// What a ScalarSensor does with values outside [MinValue, MaxValue).
+type OutOfRangePolicy int
+
+const (
+	// Returns an error, and encodes nothing.
+	OutOfRangeError OutOfRangePolicy = iota
+	// Encodes the value as the first or last bucket.
+	OutOfRangeClip
+	// Expands the range to include the value, keeping the number of buckets, so
+	// the buckets get wider and earlier encodings decode to different values.
+	OutOfRangeExpand
+	// Encodes a dedicated pattern of W bits spread over N, which barely overlaps
+	// with any bucket. Decode() returns NaN for it.
+	OutOfRangePattern
+)
+
+func (p OutOfRangePolicy) String() string {
+	switch p {
+	case OutOfRangeError:
+		return "error"
+	case OutOfRangeClip:
+		return "clip"
+	case OutOfRangeExpand:
+		return "expand"
+	case OutOfRangePattern:
+		return "pattern"
+	default:
+		return fmt.Sprintf("OutOfRangePolicy(%d)", int(p))
+	}
+}
+
 type ScalarSensor struct {
 	*Sensor
 	MaxValue   float64
 	MinValue   float64
 	BucketSize float64
+	// Defaults to OutOfRangeError.
+	OutOfRange OutOfRangePolicy
 }
 
 func (s ScalarSensor) String() string {
@@ -59,26 +98,130 @@ func (s *ScalarSensor) Encode(value interface{}) error {
 }
 
 func (s *ScalarSensor) EncodeFloat(value float64) error {
-	if value < s.MinValue || value >= s.MaxValue {
-		return fmt.Errorf("Precondition failed: min (%f) <= value (%f) < max (%f).",
-			s.MinValue, value, s.MaxValue)
+	if s.outOfRange(value) {
+		switch s.OutOfRange {
+		case OutOfRangeExpand:
+			s.expand(value)
+		case OutOfRangePattern:
+			s.value.Set(s.outOfRangeBits()...)
+			return nil
+		}
+	}
+	bucket, err := s.floatBucket(value)
+	if err != nil {
+		return err
 	}
-
-	bucket := int(math.Floor((value - s.MinValue) / s.BucketSize))
 	s.value.SetRange(bucket, bucket+s.W)
 	return nil
 }
 
+func (s ScalarSensor) outOfRange(value float64) bool {
+	return value < s.MinValue || value >= s.MaxValue
+}
+
+// Returns the bucket of a value, clipping it if the policy is OutOfRangeClip.
+func (s ScalarSensor) floatBucket(value float64) (int, error) {
+	if math.IsNaN(value) {
+		return 0, fmt.Errorf("Cannot encode %f.", value)
+	}
+	last := s.NumBuckets() - 1
+	if s.outOfRange(value) {
+		if s.OutOfRange != OutOfRangeClip {
+			return 0, fmt.Errorf("Precondition failed: min (%f) <= value (%f) < max (%f).",
+				s.MinValue, value, s.MaxValue)
+		} else if value < s.MinValue {
+			return 0, nil
+		}
+		return last, nil
+	}
+	bucket := int(math.Floor((value - s.MinValue) / s.BucketSize))
+	if bucket > last {
+		// Rounding, or a range that is not a multiple of the resolution.
+		bucket = last
+	}
+	return bucket, nil
+}
+
+// Grows the range so that value falls into the first or last bucket.
+func (s *ScalarSensor) expand(value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	if value < s.MinValue {
+		s.MinValue = value
+	} else {
+		s.MaxValue = value + s.BucketSize
+	}
+	s.BucketSize = (s.MaxValue - s.MinValue) / float64(s.NumBuckets())
+}
+
+// The W bits of OutOfRangePattern, evenly spaced so that no window of W contiguous
+// bits has more than one of them when N >= W*W.
+func (s ScalarSensor) outOfRangeBits() []int {
+	result := make([]int, s.W)
+	for i := range result {
+		result[i] = i * s.N / s.W
+	}
+	return result
+}
+
+func (s ScalarSensor) Bucket(value interface{}) (int, error) {
+	switch value := value.(type) {
+	case int:
+		return s.floatBucket(float64(value))
+	case float64:
+		return s.floatBucket(value)
+	default:
+		return 0, fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+func (s ScalarSensor) NumBuckets() int {
+	return s.N - s.W + 1
+}
+
+func (s ScalarSensor) Description() string {
+	if s.OutOfRange != OutOfRangeError {
+		return fmt.Sprintf("scalar(n=%d, w=%d, range=[%v, %v), bucket=%v, out of range=%v)",
+			s.N, s.W, s.MinValue, s.MaxValue, s.BucketSize, s.OutOfRange)
+	}
+	return fmt.Sprintf("scalar(n=%d, w=%d, range=[%v, %v), bucket=%v)",
+		s.N, s.W, s.MinValue, s.MaxValue, s.BucketSize)
+}
+
 func (s *ScalarSensor) EncodeInt(value int) error {
 	return s.EncodeFloat(float64(value))
 }
 
+// Decodes the bits into the center of the bucket with the best overlap. With
+// OutOfRangePattern, returns NaN if the pattern overlaps more than any bucket.
 func (s ScalarSensor) Decode(bits data.Bitset) interface{} {
-	found := bits.Any(func(int) bool {
-		return true
-	})
+	overlaps := s.bucketOverlaps(bits)
+	best := bestBucket(overlaps)
+	if s.OutOfRange == OutOfRangePattern {
+		pattern := 0
+		for _, v := range s.outOfRangeBits() {
+			if bits.IsSet(v) {
+				pattern++
+			}
+		}
+		if pattern > overlaps[best] {
+			return math.NaN()
+		}
+	}
+	return s.bucketValue(best)
+}
+
+func (s ScalarSensor) DecodeCandidates(bits data.Bitset, k int) []Candidate {
+	return rankCandidates(s.bucketOverlaps(bits), k, s.bucketValue)
+}
+
+func (s ScalarSensor) bucketOverlaps(bits data.Bitset) []int {
+	return windowOverlaps(bits, s.W, s.NumBuckets())
+}
 
-	return (0.5+float64(found))*s.BucketSize + s.MinValue
+func (s ScalarSensor) bucketValue(bucket int) interface{} {
+	return (0.5+float64(bucket))*s.BucketSize + s.MinValue
 }
 
 func (s ScalarSensor) DecodeInt(bits data.Bitset) int {
@@ -86,12 +229,16 @@ func (s ScalarSensor) DecodeInt(bits data.Bitset) int {
 	return int(math.Floor(floatResult))
 }
 
+// Creates a scalar sensor for [min, max) with n-w+1 buckets, which can be smaller
+// than 1.
 func NewScalarSensor(n, w int, min, max float64) (*ScalarSensor, error) {
-	BucketSize := (max - min) / float64(n-w+1)
-	if BucketSize < 1.0 {
-		err := fmt.Errorf("Not enough buckets. Increase range or decrease length.")
-		return nil, err
+	if w <= 0 || w >= n {
+		return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", w, n)
+	}
+	if max <= min {
+		return nil, fmt.Errorf("Need min (%f) < max (%f).", min, max)
 	}
+	BucketSize := (max - min) / float64(n-w+1)
 	result := ScalarSensor{
 		Sensor:     NewSensor(n, w),
 		MaxValue:   max,
@@ -101,6 +248,28 @@ func NewScalarSensor(n, w int, min, max float64) (*ScalarSensor, error) {
 	return &result, nil
 }
 
+// Creates a scalar sensor for [min, max) with buckets of the given size, which can
+// be fractional. The number of bits is derived from the range, resolution and w.
+func NewScalarSensorWithResolution(w int, min, max, resolution float64) (*ScalarSensor, error) {
+	if w <= 0 {
+		return nil, fmt.Errorf("W must be positive, but is %d.", w)
+	}
+	if max <= min {
+		return nil, fmt.Errorf("Need min (%f) < max (%f).", min, max)
+	}
+	if resolution <= 0 {
+		return nil, fmt.Errorf("Resolution must be positive, but is %f.", resolution)
+	}
+	buckets := int(math.Ceil((max - min) / resolution))
+	result := &ScalarSensor{
+		Sensor:     NewSensor(buckets+w-1, w),
+		MaxValue:   max,
+		MinValue:   min,
+		BucketSize: resolution,
+	}
+	return result, nil
+}
+
 type CategorySensor struct {
 	*Sensor
 	categories map[string]int
@@ -118,21 +287,57 @@ func (s *CategorySensor) Encode(value interface{}) error {
 	}
 }
 
+// Decodes the bits into the category with the best overlap.
 func (s *CategorySensor) Decode(bits data.Bitset) interface{} {
-	found := bits.Any(func(int) bool {
-		return true
+	return s.reverse[bestBucket(s.bucketOverlaps(bits))]
+}
+
+func (s CategorySensor) DecodeCandidates(bits data.Bitset, k int) []Candidate {
+	return rankCandidates(s.bucketOverlaps(bits), k, func(bucket int) interface{} {
+		return s.reverse[bucket]
 	})
-	return s.reverse[found/s.W]
+}
+
+func (s CategorySensor) bucketOverlaps(bits data.Bitset) []int {
+	result := make([]int, len(s.reverse))
+	for b := range result {
+		for i := b * s.W; i < (b+1)*s.W; i++ {
+			if bits.IsSet(i) {
+				result[b]++
+			}
+		}
+	}
+	return result
 }
 
 func (s *CategorySensor) EncodeString(cat string) error {
+	bucket, err := s.Bucket(cat)
+	if err != nil {
+		return err
+	}
+	s.value.SetRange(bucket*s.W, (bucket+1)*s.W)
+	return nil
+}
+
+func (s CategorySensor) Bucket(value interface{}) (int, error) {
+	cat, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
 	id, ok := s.categories[cat]
 	if !ok {
-		return fmt.Errorf(
-			"Unknown category \"%s\" in sensor: %v", cat, *s)
+		return 0, fmt.Errorf(
+			"Unknown category \"%s\" in sensor: %v", cat, s)
 	}
-	s.value.SetRange((id-1)*s.W, id*s.W)
-	return nil
+	return id - 1, nil
+}
+
+func (s CategorySensor) NumBuckets() int {
+	return len(s.reverse)
+}
+
+func (s CategorySensor) Description() string {
+	return fmt.Sprintf("category(n=%d, w=%d, categories=%v)", s.N, s.W, s.reverse)
 }
 
 func NewCategorySensor(n, w int, categories ...string) (*CategorySensor, error) {
@@ -148,12 +353,25 @@ func NewCategorySensor(n, w int, categories ...string) (*CategorySensor, error)
 	return result, nil
 }
 
+// Encodes values in [First, Last] on a circle, so that Last is as close to First
+// as it is to Last-1. The bits form a ring: each bucket of Resolution values sets
+// W contiguous bits (wrapping around) and the buckets start at evenly spaced
+// positions, so neighboring buckets overlap when the spacing is less than W.
 type PeriodicSensor struct {
 	*Sensor
 	First int
 	Last  int
+	// The number of consecutive values in each bucket.
+	Resolution float64
+
+	buckets int
+	// The number of bits in the ring, from bit 0. Bits past the ring are never set.
+	ring int
 }
 
+// Creates a periodic sensor for [first, last] with n bits, 3 of which are set for
+// each value. Each value has its own bucket, one bit after the previous one, so
+// only the first last-first+1 bits are used.
 func NewPeriodicSensor(n, first, last int) (*PeriodicSensor, error) {
 	if last <= first {
 		return nil, fmt.Errorf("Last (%d) cannot be less than first (%d)", last, first)
@@ -163,47 +381,153 @@ func NewPeriodicSensor(n, first, last int) (*PeriodicSensor, error) {
 		return nil, fmt.Errorf("Cannot fit %d (last-first) states into %d bits (n=%d, w=%d).",
 			last-first, n-w, n, w)
 	}
+	result, err := NewPeriodicSensorWithResolution(n, w, first, last, 1)
+	if err != nil {
+		return nil, err
+	}
+	// Small ranges still need a ring wider than w, or all values would look alike.
+	result.ring = max(result.buckets, w+1)
+	return result, nil
+}
+
+// Creates a periodic sensor for [first, last], with n bits, w of which are set
+// for each value. The number of buckets is (last-first+1)/resolution, rounded up,
+// and must not be more than n. The buckets are spread over all n bits.
+func NewPeriodicSensorWithResolution(n, w, first, last int, resolution float64) (*PeriodicSensor, error) {
+	if last <= first {
+		return nil, fmt.Errorf("Last (%d) cannot be less than first (%d)", last, first)
+	}
+	if w <= 0 || w >= n {
+		return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", w, n)
+	}
+	if resolution <= 0 {
+		return nil, fmt.Errorf("Resolution must be positive, but is %f.", resolution)
+	}
+	buckets := int(math.Ceil(float64(last-first+1) / resolution))
+	if buckets > n {
+		return nil, fmt.Errorf("Cannot fit %d buckets into %d bits (n=%d, w=%d).",
+			buckets, n, n, w)
+	}
 	result := &PeriodicSensor{
-		Sensor: NewSensor(n, w),
-		First:  first,
-		Last:   last,
+		Sensor:     NewSensor(n, w),
+		First:      first,
+		Last:       last,
+		Resolution: resolution,
+		buckets:    buckets,
+		ring:       n,
 	}
 	return result, nil
 }
 
+// Encodes an int in [First, Last] or a float64 in [First, Last+1).
 func (s *PeriodicSensor) Encode(value interface{}) error {
 	s.input = value
 	s.value.Reset()
 	switch value := value.(type) {
 	case int:
 		return s.EncodeInt(value)
+	case float64:
+		return s.EncodeFloat(value)
 	default:
 		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
 	}
 }
 
+// Decodes the bits into the first value of the bucket with the best overlap, so
+// noisy or partial encodings still decode to the closest value.
 func (s *PeriodicSensor) Decode(bits data.Bitset) interface{} {
-	found := bits.Any(func(int) bool {
-		return true
-	})
-	if bits.IsSet(found + 2) {
-		return found + 1 + s.First
-	} else if bits.IsSet(found + 1) {
-		// found is the center bit, so first.
-		return s.First
-	} else {
-		// found is center bit + 1, so last.
-		return s.Last
+	return s.bucketValue(bestBucket(s.bucketOverlaps(bits)))
+}
+
+// Like Decode(), but keeps the fractional part of the first value in the bucket.
+func (s PeriodicSensor) DecodeFloat(bits data.Bitset) float64 {
+	return float64(s.First) + float64(bestBucket(s.bucketOverlaps(bits)))*s.Resolution
+}
+
+// Candidate values are ints, like Decode().
+func (s PeriodicSensor) DecodeCandidates(bits data.Bitset, k int) []Candidate {
+	return rankCandidates(s.bucketOverlaps(bits), k, s.bucketValue)
+}
+
+func (s PeriodicSensor) bucketOverlaps(bits data.Bitset) []int {
+	result := make([]int, s.buckets)
+	for b := range result {
+		start := s.bucketStart(b)
+		for i := 0; i < s.W; i++ {
+			if bits.IsSet((start + i) % s.ring) {
+				result[b]++
+			}
+		}
+	}
+	return result
+}
+
+func (s PeriodicSensor) bucketValue(bucket int) interface{} {
+	return s.First + int(math.Floor(float64(bucket)*s.Resolution))
+}
+
+// The first bit of a bucket on the ring.
+func (s PeriodicSensor) bucketStart(bucket int) int {
+	return bucket * s.ring / s.buckets
+}
+
+func (s PeriodicSensor) floatBucket(v float64) (int, error) {
+	if math.IsNaN(v) || v < float64(s.First) || v >= float64(s.Last+1) {
+		return 0, fmt.Errorf("Precondition failed: min (%d) <= value (%v) < max (%d).",
+			s.First, v, s.Last+1)
 	}
+	b := int(math.Floor((v - float64(s.First)) / s.Resolution))
+	if b >= s.buckets {
+		b = s.buckets - 1
+	}
+	return b, nil
+}
+
+func (s PeriodicSensor) Bucket(value interface{}) (int, error) {
+	switch value := value.(type) {
+	case int:
+		if value < s.First || value > s.Last {
+			return 0, fmt.Errorf("Precondition failed: min (%d) <= value (%d) <= max (%d).",
+				s.First, value, s.Last)
+		}
+		return s.floatBucket(float64(value))
+	case float64:
+		return s.floatBucket(value)
+	default:
+		return 0, fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+func (s PeriodicSensor) NumBuckets() int {
+	return s.buckets
+}
+
+func (s PeriodicSensor) Description() string {
+	return fmt.Sprintf("periodic(n=%d, w=%d, range=[%d, %d], resolution=%v)",
+		s.N, s.W, s.First, s.Last, s.Resolution)
 }
 
 func (s *PeriodicSensor) EncodeInt(value int) error {
-	if value < s.First || value > s.Last {
-		return fmt.Errorf("Precondition failed: min (%d) <= value (%d) < max (%d).",
-			s.First, value, s.Last)
+	bucket, err := s.Bucket(value)
+	if err != nil {
+		return err
 	}
-	centerBit := value - s.First
-	sz := s.Last - s.First
-	s.value.Set((centerBit-1+sz)%sz, centerBit, (centerBit+1)%sz)
+	s.encodeBucket(bucket)
 	return nil
 }
+
+func (s *PeriodicSensor) EncodeFloat(value float64) error {
+	bucket, err := s.floatBucket(value)
+	if err != nil {
+		return err
+	}
+	s.encodeBucket(bucket)
+	return nil
+}
+
+func (s *PeriodicSensor) encodeBucket(bucket int) {
+	start := s.bucketStart(bucket)
+	for i := 0; i < s.W; i++ {
+		s.value.Set((start + i) % s.ring)
+	}
+}
@@ -1,6 +1,7 @@
 package input
 
 import "github.com/dukejeffrie/htm/data"
+import "math"
 import "testing"
 
 func TestRoundEncoder(t *testing.T) {
@@ -119,9 +120,105 @@ func TestFloatEncoder(t *testing.T) {
 }
 
 func TestSparseEncoder(t *testing.T) {
-	_, err := NewScalarSensor(2048, 3, -100, 100)
-	if err == nil {
-		t.Fatal("Sensor cannot be too sparse.")
+	s, err := NewScalarSensor(2048, 3, -100, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.BucketSize >= 1.0 {
+		t.Fatalf("Buckets should be fractional, but are %f.", s.BucketSize)
+	}
+	for _, v := range []float64{-100, -0.05, 0.05, 99.9} {
+		if err := s.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+		if decoded := s.Decode(s.Get()).(float64); math.Abs(decoded-v) > s.BucketSize {
+			t.Errorf("Decode(Encode(%f)) = %f", v, decoded)
+		}
+	}
+	if _, err := NewScalarSensor(64, 64, -100, 100); err == nil {
+		t.Error("Should fail with w >= n.")
+	}
+	if _, err := NewScalarSensor(64, 3, 100, 100); err == nil {
+		t.Error("Should fail with an empty range.")
+	}
+}
+
+func TestScalarEncoder_Resolution(t *testing.T) {
+	s, err := NewScalarSensorWithResolution(3, -1, 1, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.N != 22 || s.NumBuckets() != 20 {
+		t.Errorf("Bad size: n=%d, buckets=%d", s.N, s.NumBuckets())
+	}
+	s.Encode(0.25)
+	if b, _ := s.Bucket(0.25); b != 12 || !s.Get().AllSet(12, 13, 14) {
+		t.Errorf("Encode failed: %v", *s)
+	}
+	if v := s.Decode(s.Get()).(float64); math.Abs(v-0.25) > 1e-9 {
+		t.Errorf("Decode failed. Expected: %v, but got: %v", 0.25, v)
+	}
+	if _, err := NewScalarSensorWithResolution(3, 1, 1, 0.1); err == nil {
+		t.Error("Should fail with an empty range.")
+	}
+}
+
+func TestScalarEncoder_OutOfRange(t *testing.T) {
+	s, err := NewScalarSensor(64, 4, 0, 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Encode(-1); err == nil {
+		t.Error("Should fail by default.")
+	}
+
+	s.OutOfRange = OutOfRangeClip
+	if err := s.Encode(1000); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Get().AllSet(60, 61, 62, 63) {
+		t.Errorf("Should clip to the last bucket: %v", s.Get())
+	}
+	s.Encode(-5.5)
+	if !s.Get().AllSet(0, 1, 2, 3) {
+		t.Errorf("Should clip to the first bucket: %v", s.Get())
+	}
+	if b, err := s.Bucket(500); err != nil || b != s.NumBuckets()-1 {
+		t.Errorf("Bucket should clip, but got: %d, %v", b, err)
+	}
+
+	s.OutOfRange = OutOfRangePattern
+	s.Encode(-1)
+	pattern := s.Get().Clone()
+	if !pattern.AllSet(0, 16, 32, 48) || pattern.NumSetBits() != 4 {
+		t.Errorf("Bad out of range pattern: %v", pattern)
+	}
+	if v := s.Decode(*pattern).(float64); !math.IsNaN(v) {
+		t.Errorf("Should decode the pattern as NaN, but got: %v", v)
+	}
+	s.Encode(60)
+	if v := s.Decode(s.Get()).(float64); math.IsNaN(v) {
+		t.Errorf("Should decode in range values, but got: %v", v)
+	}
+
+	s.OutOfRange = OutOfRangeExpand
+	if err := s.Encode(240.0); err != nil {
+		t.Fatal(err)
+	}
+	if s.MaxValue <= 240 || !s.Get().AllSet(60, 61, 62, 63) {
+		t.Errorf("Should expand the range: %v", *s)
+	}
+	if err := s.Encode(-240.0); err != nil {
+		t.Fatal(err)
+	}
+	if s.MinValue != -240 || !s.Get().AllSet(0, 1, 2, 3) {
+		t.Errorf("Should expand the range: %v", *s)
+	}
+	if v := s.Decode(s.Get()).(float64); v < -240 || v > -240+s.BucketSize {
+		t.Errorf("Decode failed: %v (%v)", v, *s)
+	}
+	if err := s.Encode(math.NaN()); err == nil {
+		t.Error("Should fail to encode NaN.")
 	}
 }
 
@@ -198,3 +295,93 @@ func TestPeriodicEncoder(t *testing.T) {
 		t.Errorf("Sunday(%v) and Monday(%v) should overlap on one bit.", eSu, eTu)
 	}
 }
+
+func TestPeriodicEncoder_WithResolution(t *testing.T) {
+	// Monday to Sunday.
+	mo, tu, we, th, fr, sa, su := 1, 2, 3, 4, 5, 6, 7
+	week := []int{mo, tu, we, th, fr, sa, su}
+	s, err := NewPeriodicSensorWithResolution(14, 4, mo, su, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, day := range week {
+		if err = s.Encode(day); err != nil {
+			t.Error("Could not encode day: ", day, ", error: ", err)
+		}
+		if s.Decode(s.Get()) != day {
+			t.Errorf("Decode failed, expected %d but got: %d (%v)", day, s.Decode(s.Get()), *s)
+		}
+	}
+	if t.Failed() {
+		return
+	}
+	s.Encode(mo)
+	eMo := s.Get().Clone()
+	if eMo.NumSetBits() != s.W {
+		t.Errorf("Should have %d bits set, but has %d.", s.W, eMo.NumSetBits())
+	}
+	eTu := data.NewBitset(s.N)
+	eMo.Foreach(func(i int) {
+		eTu.Set((i + 2) % s.N)
+	})
+	if tu != s.Decode(*eTu) {
+		t.Errorf("Decode(%v) failed, expected %d but got: %d (%v)", eTu, tu, s.Decode(*eTu), *s)
+	}
+
+	s.Encode(su)
+	eSu := s.Get().Clone()
+	if eSu.Overlap(*eMo) != 2 {
+		t.Errorf("Sunday(%v) and Monday(%v) should overlap on two bits.", eSu, eMo)
+	}
+	if eSu.Overlap(*eTu) != 0 {
+		t.Errorf("Sunday(%v) and Tuesday(%v) should not overlap.", eSu, eTu)
+	}
+	s.Encode(sa)
+	if eSu.Overlap(s.Get()) != 2 {
+		t.Errorf("Sunday(%v) and Saturday(%v) should overlap on two bits.", eSu, s.Get())
+	}
+
+	// Partial and noisy encodings of Thursday.
+	s.Encode(th)
+	noisy := s.Get().Clone()
+	noisy.Unset(noisy.Indices()[0])
+	noisy.Set(0)
+	if th != s.Decode(*noisy) {
+		t.Errorf("Decode(%v) failed, expected %d but got: %d", noisy, th, s.Decode(*noisy))
+	}
+	if err := s.Encode(8); err == nil {
+		t.Errorf("Should fail to encode a value after Last.")
+	}
+	if _, err := NewPeriodicSensorWithResolution(6, 3, mo, su, 1); err == nil {
+		t.Errorf("Should fail to fit 7 buckets into 6 bits.")
+	}
+}
+
+func TestPeriodicEncoder_Resolution(t *testing.T) {
+	// Hours of the day, in half-hour buckets.
+	s, err := NewPeriodicSensorWithResolution(96, 5, 0, 23, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.NumBuckets() != 48 {
+		t.Errorf("Should have %d buckets, but has %d", 48, s.NumBuckets())
+	}
+	if err := s.Encode(13.75); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.DecodeFloat(s.Get()); v != 13.5 {
+		t.Errorf("Decode failed, expected %v but got: %v", 13.5, v)
+	}
+	if v := s.Decode(s.Get()); v != 13 {
+		t.Errorf("Decode failed, expected %v but got: %v", 13, v)
+	}
+	s.Encode(23.9)
+	late := s.Get().Clone()
+	s.Encode(0)
+	if late.Overlap(s.Get()) == 0 {
+		t.Errorf("Late night (%v) and midnight (%v) should overlap.", late, s.Get())
+	}
+	if err := s.Encode(24.0); err == nil {
+		t.Errorf("Should fail to encode 24.")
+	}
+}
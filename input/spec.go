@@ -0,0 +1,249 @@
+package input
+
+import "bytes"
+import "encoding/json"
+import "fmt"
+import "io"
+import "os"
+import "strings"
+
+// Describes the encoder of one field, as read from JSON. Which parameters are
+// needed depends on the type:
+//
+//	scalar:            w, range [min, max], and either n or resolution
+//	log:               w, range [min, max], resolution, and optionally base (10)
+//	periodic:          n, w, range [first, last], and optionally resolution (1)
+//	category:          n, w, categories
+//	random_category:   n, w, categories, and optionally capacity and seed
+//	semantic_category: n, w, categories (paths like "animal/dog"), and optionally seed
+//	rdse:              n, w, resolution, and optionally seed
+//	pass_through:      n, w
+//
+// Parameters the type does not use are rejected, so typos do not go unnoticed.
+type FieldSpec struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	N          int       `json:"n,omitempty"`
+	W          int       `json:"w,omitempty"`
+	Range      []float64 `json:"range,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+	Resolution float64   `json:"resolution,omitempty"`
+	Base       float64   `json:"base,omitempty"`
+	Capacity   int       `json:"capacity,omitempty"`
+	Seed       int64     `json:"seed,omitempty"`
+}
+
+// Describes a MultiEncoder, with its fields in layout order.
+type EncoderSpec struct {
+	Fields []FieldSpec `json:"fields"`
+}
+
+// Reads an encoder spec from JSON. Unknown keys are errors. Each field is read on
+// its own, so that errors name the field.
+func ParseEncoderSpec(reader io.Reader) (*EncoderSpec, error) {
+	var raw struct {
+		Fields []json.RawMessage `json:"fields"`
+	}
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("Bad encoder spec: %v", err)
+	}
+	result := &EncoderSpec{Fields: make([]FieldSpec, len(raw.Fields))}
+	for i, r := range raw.Fields {
+		if err := parseFieldSpec(r, &result.Fields[i]); err != nil {
+			return nil, fmt.Errorf("Bad encoder spec: %v", err)
+		}
+	}
+	return result, nil
+}
+
+func parseFieldSpec(raw json.RawMessage, f *FieldSpec) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(f); err != nil {
+		// Read just the name, if there is one, to say which field is bad.
+		var named struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(raw, &named) == nil && named.Name != "" {
+			return fmt.Errorf("Field \"%s\": %v", named.Name, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Reads the encoder spec in a JSON file and builds its encoder.
+func LoadEncoderSpec(path string) (*MultiEncoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	spec, err := ParseEncoderSpec(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return spec.Build()
+}
+
+// Builds a MultiEncoder with one encoder per field. Errors name the field.
+func (spec EncoderSpec) Build() (*MultiEncoder, error) {
+	if len(spec.Fields) == 0 {
+		return nil, fmt.Errorf("Encoder spec has no fields.")
+	}
+	fields := make([]Field, len(spec.Fields))
+	seen := make(map[string]bool, len(spec.Fields))
+	for i, f := range spec.Fields {
+		if f.Name == "" {
+			return nil, fmt.Errorf("Field #%d has no name.", i+1)
+		}
+		if seen[f.Name] {
+			return nil, fmt.Errorf("Duplicate field \"%s\".", f.Name)
+		}
+		seen[f.Name] = true
+		e, err := f.Build()
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = Field{f.Name, e}
+	}
+	return NewMultiEncoder(fields...)
+}
+
+// Builds the encoder of a single field.
+func (f FieldSpec) Build() (Encoder, error) {
+	e, err := f.build()
+	if err != nil {
+		return nil, fmt.Errorf("Field \"%s\": %v", f.Name, err)
+	}
+	return e, nil
+}
+
+func (f FieldSpec) build() (Encoder, error) {
+	switch f.Type {
+	case "scalar":
+		if err := f.check("w", "range", "n|resolution"); err != nil {
+			return nil, err
+		}
+		if f.N > 0 {
+			if f.Resolution != 0 {
+				return nil, fmt.Errorf("Give either n or resolution, not both.")
+			}
+			if f.W >= f.N {
+				return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", f.W, f.N)
+			}
+			return NewScalarSensor(f.N, f.W, f.Range[0], f.Range[1])
+		}
+		return NewScalarSensorWithResolution(f.W, f.Range[0], f.Range[1], f.Resolution)
+	case "log":
+		if err := f.check("w", "range", "resolution", "base?"); err != nil {
+			return nil, err
+		}
+		base := f.Base
+		if base == 0 {
+			base = 10
+		}
+		return NewLogScalarSensor(f.W, base, f.Range[0], f.Range[1], f.Resolution)
+	case "periodic":
+		if err := f.check("n", "w", "range", "resolution?"); err != nil {
+			return nil, err
+		}
+		first, last := int(f.Range[0]), int(f.Range[1])
+		if float64(first) != f.Range[0] || float64(last) != f.Range[1] {
+			return nil, fmt.Errorf("Periodic range must be integers, but is %v.", f.Range)
+		}
+		resolution := f.Resolution
+		if resolution == 0 {
+			resolution = 1
+		}
+		return NewPeriodicSensorWithResolution(f.N, f.W, first, last, resolution)
+	case "category":
+		if err := f.check("n", "w", "categories"); err != nil {
+			return nil, err
+		}
+		if f.W*len(f.Categories) > f.N {
+			return nil, fmt.Errorf("Cannot fit %d categories of w=%d into n=%d.",
+				len(f.Categories), f.W, f.N)
+		}
+		return NewCategorySensor(f.N, f.W, f.Categories...)
+	case "random_category":
+		if err := f.check("n", "w", "categories", "capacity?", "seed?"); err != nil {
+			return nil, err
+		}
+		capacity := f.Capacity
+		if capacity == 0 {
+			capacity = len(f.Categories)
+		}
+		return NewRandomCategorySensor(f.N, f.W, capacity, f.Seed, f.Categories...)
+	case "semantic_category":
+		if err := f.check("n", "w", "categories", "seed?"); err != nil {
+			return nil, err
+		}
+		return NewSemanticCategorySensor(f.N, f.W, f.Seed, f.Categories...)
+	case "rdse":
+		if err := f.check("n", "w", "resolution", "seed?"); err != nil {
+			return nil, err
+		}
+		return NewRandomDistributedScalarSensor(f.N, f.W, f.Resolution, 0, f.Seed)
+	case "pass_through":
+		if err := f.check("n", "w"); err != nil {
+			return nil, err
+		}
+		return NewPassThroughSensor(f.N, f.W)
+	case "":
+		return nil, fmt.Errorf("Missing type.")
+	default:
+		return nil, fmt.Errorf("Unknown type \"%s\".", f.Type)
+	}
+}
+
+// Checks that the spec has the given parameters, and no others. Parameters ending
+// in "?" are optional; "a|b" means a or b.
+func (f FieldSpec) check(params ...string) error {
+	given := map[string]bool{
+		"n":          f.N != 0,
+		"w":          f.W != 0,
+		"range":      f.Range != nil,
+		"categories": f.Categories != nil,
+		"resolution": f.Resolution != 0,
+		"base":       f.Base != 0,
+		"capacity":   f.Capacity != 0,
+		"seed":       f.Seed != 0,
+	}
+	allowed := make(map[string]bool, len(params))
+	for _, p := range params {
+		optional := p[len(p)-1] == '?'
+		if optional {
+			p = p[:len(p)-1]
+		}
+		alternatives := strings.Split(p, "|")
+		found := false
+		for _, a := range alternatives {
+			allowed[a] = true
+			found = found || given[a]
+		}
+		if !found && !optional {
+			return fmt.Errorf("Missing %s for type %s.", strings.Join(alternatives, " or "), f.Type)
+		}
+	}
+	for _, p := range []string{"n", "w", "range", "categories", "resolution", "base", "capacity", "seed"} {
+		if given[p] && !allowed[p] {
+			return fmt.Errorf("Type %s does not use %s.", f.Type, p)
+		}
+	}
+	if given["n"] && f.N < 0 {
+		return fmt.Errorf("N must be positive, but is %d.", f.N)
+	}
+	if f.W < 0 {
+		return fmt.Errorf("W must be positive, but is %d.", f.W)
+	}
+	if given["range"] && (len(f.Range) != 2 || f.Range[1] <= f.Range[0]) {
+		return fmt.Errorf("Range must be [min, max] with min < max, but is %v.", f.Range)
+	}
+	if given["categories"] && len(f.Categories) == 0 {
+		return fmt.Errorf("Categories must not be empty.")
+	}
+	return nil
+}
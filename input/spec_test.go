@@ -0,0 +1,119 @@
+package input
+
+import "os"
+import "path/filepath"
+import "strings"
+import "testing"
+
+const testSpec = `{
+	"fields": [
+		{"name": "price", "type": "scalar", "n": 44, "w": 4, "range": [0, 100]},
+		{"name": "volume", "type": "log", "w": 3, "range": [1, 1000], "resolution": 0.5},
+		{"name": "hour", "type": "periodic", "n": 24, "w": 3, "range": [0, 23]},
+		{"name": "color", "type": "category", "n": 12, "w": 4, "categories": ["red", "green", "blue"]},
+		{"name": "animal", "type": "semantic_category", "n": 64, "w": 8, "seed": 7,
+			"categories": ["mammal/dog", "mammal/cat"]},
+		{"name": "delta", "type": "rdse", "n": 100, "w": 5, "resolution": 0.5, "seed": 3}
+	]
+}`
+
+func TestParseEncoderSpec(t *testing.T) {
+	spec, err := ParseEncoderSpec(strings.NewReader(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := spec.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"price", "volume", "hour", "color", "animal", "delta"}
+	if len(m.Fields()) != len(names) {
+		t.Fatalf("Expected %d fields, but got: %v", len(names), m.Fields())
+	}
+	for i, f := range m.Fields() {
+		if f.Name != names[i] {
+			t.Errorf("Bad field #%d. Expected: %s, but got: %s", i, names[i], f.Name)
+		}
+	}
+	price, _ := m.Encoder("price")
+	if price.Width() != 44 {
+		t.Errorf("Bad width for price: %d", price.Width())
+	}
+	record := map[string]interface{}{
+		"price": 50.0, "volume": 10.0, "hour": 13, "color": "green",
+		"animal": "mammal/cat", "delta": 2.0,
+	}
+	if err := m.Encode(record); err != nil {
+		t.Fatal(err)
+	}
+	decoded := m.DecodeRecord(m.Get())
+	for _, name := range []string{"hour", "color", "animal"} {
+		if decoded[name] != record[name] {
+			t.Errorf("Bad decoded %s. Expected: %v, but got: %v", name, record[name], decoded[name])
+		}
+	}
+}
+
+func TestLoadEncoderSpec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.json")
+	if err := os.WriteFile(path, []byte(testSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadEncoderSpec(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Fields()) != 6 {
+		t.Errorf("Bad fields: %v", m.Fields())
+	}
+	if _, err := LoadEncoderSpec(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Should fail with a missing file.")
+	}
+}
+
+func TestEncoderSpec_Errors(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{`{"fields": []}`, "no fields"},
+		{`{"fields": [{"type": "scalar"}]}`, "#1 has no name"},
+		{`{"fields": [{"name": "a", "type": "pass_through", "n": 8, "w": 2},
+			{"name": "a", "type": "pass_through", "n": 8, "w": 2}]}`, "Duplicate field \"a\""},
+		{`{"fields": [{"name": "x", "type": "scalar", "n": 44, "w": 4, "rnage": [0, 1]}]}`,
+			"Field \"x\": json: unknown field \"rnage\""},
+		{`{"fields": [{"name": "x", "type": "scalar", "n": "44"}]}`, "Field \"x\": json: cannot unmarshal"},
+		{`{"fields": [{"type": "scalar", "rnage": [0, 1]}]}`, "unknown field \"rnage\""},
+		{`{"feilds": []}`, "unknown field \"feilds\""},
+		{`{"fields": [{"name": "price", "type": "bogus"}]}`, "Field \"price\": Unknown type"},
+		{`{"fields": [{"name": "price"}]}`, "Field \"price\": Missing type"},
+		{`{"fields": [{"name": "price", "type": "scalar", "n": 44, "w": 4}]}`, "Field \"price\": Missing range"},
+		{`{"fields": [{"name": "price", "type": "scalar", "w": 4, "range": [0, 100]}]}`,
+			"Field \"price\": Missing n or resolution"},
+		{`{"fields": [{"name": "price", "type": "scalar", "n": 44, "w": 4, "resolution": 1, "range": [0, 100]}]}`,
+			"Field \"price\": Give either n or resolution"},
+		{`{"fields": [{"name": "price", "type": "scalar", "n": 44, "w": 4, "range": [100, 0]}]}`,
+			"Field \"price\": Range must be"},
+		{`{"fields": [{"name": "price", "type": "scalar", "n": 44, "w": 4, "range": [0, 100], "seed": 1}]}`,
+			"Field \"price\": Type scalar does not use seed"},
+		{`{"fields": [{"name": "color", "type": "category", "n": 8, "w": 4, "categories": ["r", "g", "b"]}]}`,
+			"Field \"color\": Cannot fit 3 categories"},
+		{`{"fields": [{"name": "color", "type": "category", "n": 8, "w": 4, "categories": []}]}`,
+			"Field \"color\": Categories must not be empty"},
+		{`{"fields": [{"name": "hour", "type": "periodic", "n": 24, "w": 3, "range": [0, 23.5]}]}`,
+			"Field \"hour\": Periodic range must be integers"},
+		{`{"fields": [{"name": "delta", "type": "rdse", "n": 10, "w": 20, "resolution": 1}]}`,
+			"Field \"delta\": Need 0 < w"},
+	}
+	for _, test := range tests {
+		spec, err := ParseEncoderSpec(strings.NewReader(test.spec))
+		if err == nil {
+			_, err = spec.Build()
+		}
+		if err == nil {
+			t.Errorf("Should fail to build %s", test.spec)
+		} else if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Bad error for %s. Expected: %s, but got: %v", test.spec, test.expected, err)
+		}
+	}
+}
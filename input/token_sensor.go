@@ -0,0 +1,219 @@
+package input
+
+import "fmt"
+import "hash/fnv"
+import "sort"
+import "strings"
+import "github.com/dukejeffrie/htm/data"
+
+// Default maximum number of tokens a TokenSensor remembers for decoding.
+const DefaultMaxVocabulary = 10000
+
+// Encodes strings (words, log tokens) as W bits out of N derived from a hash, so
+// any token can be encoded without a fixed vocabulary and the same token always
+// gets the same bits.
+//
+// With NGram > 0, the bits are chosen SimHash-style: each character n-gram of the
+// token votes for W bits, and the W bits with the most votes win, so tokens that
+// share n-grams ("connect", "connected") overlap. Several tokens can be encoded
+// together as a bag, which is the union of their encodings.
+type TokenSensor struct {
+	*Sensor
+	// Length of the character n-grams, or 0 to hash whole tokens, so that different
+	// tokens do not overlap more than by chance.
+	NGram int
+	Seed  int64
+	// Encoded tokens are remembered for Decode(), up to this many.
+	MaxVocabulary int
+
+	vocabulary map[string]int
+	tokens     []string
+	codes      [][]int
+}
+
+// Creates a new token sensor of n bits with w bits per token.
+func NewTokenSensor(n, w, ngram int, seed int64) (*TokenSensor, error) {
+	if w <= 0 || w >= n {
+		return nil, fmt.Errorf("Need 0 < w (%d) < n (%d).", w, n)
+	}
+	if ngram < 0 {
+		return nil, fmt.Errorf("NGram must not be negative, but is %d.", ngram)
+	}
+	result := &TokenSensor{
+		Sensor:        NewSensor(n, w),
+		NGram:         ngram,
+		Seed:          seed,
+		MaxVocabulary: DefaultMaxVocabulary,
+		vocabulary:    make(map[string]int),
+	}
+	return result, nil
+}
+
+func (s TokenSensor) String() string {
+	return fmt.Sprint(*s.Sensor, "[", len(s.tokens), " tokens]")
+}
+
+// Encodes a string as a single token, or a []string as a bag of tokens.
+func (s *TokenSensor) Encode(value interface{}) error {
+	switch value := value.(type) {
+	case string:
+		return s.EncodeToken(value)
+	case []string:
+		return s.EncodeTokens(value)
+	default:
+		s.input = value
+		s.value.Reset()
+		return fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+}
+
+func (s *TokenSensor) EncodeToken(token string) error {
+	s.input = token
+	s.value.Reset()
+	if token == "" {
+		return fmt.Errorf("Cannot encode an empty token.")
+	}
+	s.value.Set(s.code(token)...)
+	return nil
+}
+
+// Encodes the union of the tokens. The result has up to W bits per distinct token.
+func (s *TokenSensor) EncodeTokens(tokens []string) error {
+	s.input = tokens
+	s.value.Reset()
+	if len(tokens) == 0 {
+		return fmt.Errorf("Cannot encode an empty bag of tokens.")
+	}
+	for _, t := range tokens {
+		if t == "" {
+			s.value.Reset()
+			return fmt.Errorf("Cannot encode an empty token in %q.", tokens)
+		}
+		s.value.Set(s.code(t)...)
+	}
+	return nil
+}
+
+// Encodes the whitespace-separated words of text as a bag of tokens.
+func (s *TokenSensor) EncodeText(text string) error {
+	return s.EncodeTokens(strings.Fields(text))
+}
+
+// Returns the bits of a token, remembering it if there is room in the vocabulary.
+func (s *TokenSensor) code(token string) []int {
+	if id, ok := s.vocabulary[token]; ok {
+		return s.codes[id]
+	}
+	var code []int
+	if s.NGram == 0 {
+		code = s.hashBits(token)
+	} else {
+		code = s.ngramBits(token)
+	}
+	if len(s.tokens) < s.MaxVocabulary {
+		s.vocabulary[token] = len(s.tokens)
+		s.tokens = append(s.tokens, token)
+		s.codes = append(s.codes, code)
+	}
+	return code
+}
+
+// Returns W distinct bits derived from the hash of a string.
+func (s TokenSensor) hashBits(str string) []int {
+	h := fnv.New64a()
+	h.Write([]byte(str))
+	base := h.Sum64() ^ uint64(s.Seed)
+	result := make([]int, 0, s.W)
+	used := make(map[int]bool, s.W)
+	for i := uint64(0); len(result) < s.W; i++ {
+		b := int(mix64(base+i) % uint64(s.N))
+		if !used[b] {
+			used[b] = true
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+// Returns the W bits with the most votes from the n-grams of the token. The token
+// is padded with '^' and '$' so that its beginning and end also count. Ties are
+// broken by hashing each bit with the n-grams that voted for it, so the chosen
+// bits spread over the whole bitset, and tokens that share n-grams still break
+// ties on the shared bits the same way.
+func (s TokenSensor) ngramBits(token string) []int {
+	padded := []rune("^" + token + "$")
+	votes := make(map[int]int)
+	ties := make(map[int]uint64)
+	vote := func(ngram string) {
+		h := fnv.New64a()
+		h.Write([]byte(ngram))
+		tieSeed := h.Sum64() ^ uint64(s.Seed) ^ tieSalt
+		for _, b := range s.hashBits(ngram) {
+			votes[b]++
+			if tie := mix64(tieSeed ^ uint64(b)); tie > ties[b] {
+				ties[b] = tie
+			}
+		}
+	}
+	if len(padded) < s.NGram {
+		vote(string(padded))
+	}
+	for i := 0; i+s.NGram <= len(padded); i++ {
+		vote(string(padded[i : i+s.NGram]))
+	}
+	result := make([]int, 0, len(votes))
+	for b := range votes {
+		result = append(result, b)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		bi, bj := result[i], result[j]
+		if votes[bi] != votes[bj] {
+			return votes[bi] > votes[bj]
+		}
+		return ties[bi] > ties[bj] || (ties[bi] == ties[bj] && bi < bj)
+	})
+	return result[:s.W]
+}
+
+// Salt for the tie-breaking hash, so that it is independent from the bit hash.
+const tieSalt = 0x746965
+
+// Returns the index of a remembered token.
+func (s TokenSensor) Bucket(value interface{}) (int, error) {
+	token, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("Cannot encode values of type %T (%v).", value, value)
+	}
+	id, ok := s.vocabulary[token]
+	if !ok {
+		return 0, fmt.Errorf("Token \"%s\" was never encoded.", token)
+	}
+	return id, nil
+}
+
+// The number of remembered tokens.
+func (s TokenSensor) NumBuckets() int {
+	return len(s.tokens)
+}
+
+// Decodes the bits into the remembered token with the best overlap, or "" if no
+// token overlaps the bits.
+func (s TokenSensor) Decode(bits data.Bitset) interface{} {
+	best := s.DecodeCandidates(bits, 1)
+	if len(best) == 0 {
+		return ""
+	}
+	return best[0].Value
+}
+
+// Candidates are remembered tokens, best first. For a bag of tokens, the tokens in
+// the bag have an overlap of W.
+func (s TokenSensor) DecodeCandidates(bits data.Bitset, k int) []Candidate {
+	return rankCandidates(codeOverlaps(bits, s.codes), k, func(bucket int) interface{} {
+		return s.tokens[bucket]
+	})
+}
+
+func (s TokenSensor) Description() string {
+	return fmt.Sprintf("token(n=%d, w=%d, ngram=%d)", s.N, s.W, s.NGram)
+}
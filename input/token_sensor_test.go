@@ -0,0 +1,116 @@
+package input
+
+import "testing"
+
+func TestTokenSensor(t *testing.T) {
+	s, err := NewTokenSensor(1024, 16, 0, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Encode("error"); err != nil {
+		t.Fatal(err)
+	}
+	e := s.Get().Clone()
+	if e.NumSetBits() != s.W {
+		t.Errorf("Should have %d bits set, but has %d: %v", s.W, e.NumSetBits(), e)
+	}
+	other, _ := NewTokenSensor(1024, 16, 0, 42)
+	other.Encode("error")
+	if !other.Get().Equals(*e) {
+		t.Errorf("Encoding is not stable: %v vs %v", other.Get(), e)
+	}
+	s.Encode("errors")
+	if o := s.Get().Overlap(*e); o > 2 {
+		t.Errorf("Different tokens should not overlap, but overlap on %d bits.", o)
+	}
+	if v := s.Decode(*e); v != "error" {
+		t.Errorf("Decode failed. Expected: %v, but got: %v", "error", v)
+	}
+	if b, err := s.Bucket("errors"); err != nil || b != 1 {
+		t.Errorf("Bad bucket for errors: %d, %v", b, err)
+	}
+	if err := s.Encode(""); err == nil {
+		t.Error("Should fail to encode an empty token.")
+	}
+}
+
+func TestTokenSensor_NGram(t *testing.T) {
+	s, err := NewTokenSensor(1024, 16, 3, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(token string) int {
+		s.Encode(token)
+		return s.Get().NumSetBits()
+	}
+	if n := encode("connect"); n != s.W {
+		t.Errorf("Should have %d bits set, but has %d", s.W, n)
+	}
+	connect := s.Get().Clone()
+	encode("connected")
+	similar := s.Get().Overlap(*connect)
+	encode("timeout")
+	different := s.Get().Overlap(*connect)
+	if similar < s.W/2 || similar <= different {
+		t.Errorf("Similar tokens should overlap more (%d) than different ones (%d).",
+			similar, different)
+	}
+	if n := encode("a"); n != s.W {
+		t.Errorf("Short tokens should have %d bits set, but have %d", s.W, n)
+	}
+}
+
+func TestTokenSensor_NGramSpread(t *testing.T) {
+	s, err := NewTokenSensor(1024, 20, 3, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := []string{"apple", "river", "quartz", "mountain", "jazz", "velvet", "orbit",
+		"lantern", "pebble", "thunder", "cactus", "whisper", "falcon", "meadow", "glacier",
+		"saffron", "tundra", "violin", "harbor", "ember"}
+	lower := 0
+	for _, w := range words {
+		if err := s.Encode(w); err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range s.Get().Indices() {
+			if b < s.N/2 {
+				lower++
+			}
+		}
+	}
+	// 400 bits in total, so about 200 should fall in each half.
+	if lower < 150 || lower > 250 {
+		t.Errorf("Bits should spread over the whole bitset, but %d of %d are in the lower half.",
+			lower, len(words)*s.W)
+	}
+}
+
+func TestTokenSensor_Bag(t *testing.T) {
+	s, err := NewTokenSensor(2048, 20, 0, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EncodeText("connection reset by peer"); err != nil {
+		t.Fatal(err)
+	}
+	bag := s.Get().Clone()
+	if bag.NumSetBits() < 4*s.W-4 {
+		t.Errorf("Bag should have about %d bits set, but has %d", 4*s.W, bag.NumSetBits())
+	}
+	closest := s.DecodeCandidates(*bag, 4)
+	if len(closest) != 4 {
+		t.Fatalf("Bad closest tokens: %v", closest)
+	}
+	for _, m := range closest {
+		if m.Overlap != s.W {
+			t.Errorf("Token %s should be fully in the bag: %v", m.Value, closest)
+		}
+	}
+	if err := s.Encode([]string{}); err == nil {
+		t.Error("Should fail to encode an empty bag.")
+	}
+	if err := s.Encode([]string{"a", ""}); err == nil {
+		t.Error("Should fail to encode an empty token in a bag.")
+	}
+}
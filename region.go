@@ -7,6 +7,7 @@ import "container/heap"
 import "fmt"
 import "github.com/dukejeffrie/htm/data"
 import "github.com/dukejeffrie/htm/log"
+import "github.com/dukejeffrie/htm/segment"
 import "io"
 
 type ScoredElement struct {
@@ -56,6 +57,9 @@ type RegionParameters struct {
 	// Minimum overlap between an input and a column's proximal dentrite to trigger
 	// activation.
 	MinimumInputOverlap int
+	// Number of discrete permanence steps for all segments in this region, see
+	// segment.PermanenceConfiguration. Zero stores permanences as float32.
+	PermanenceSteps uint16
 }
 
 type Region struct {
@@ -70,6 +74,9 @@ type Region struct {
 	learnActiveStateLast *data.Bitset
 	learnPredictiveState *data.Bitset
 	scores               TopN
+	// Sparse copy of the input, used when overlapping it is cheaper than scanning
+	// the dense form.
+	sparseInput *data.SparseBitset
 }
 
 // Creates a new named region with the given parameters.
@@ -86,9 +93,12 @@ func NewRegion(params RegionParameters) *Region {
 		learnActiveStateLast: data.NewBitset(params.Width * params.Height),
 		learnPredictiveState: data.NewBitset(params.Width * params.Height),
 		scores:               make([]ScoredElement, 0, params.MaximumFiringColumns+1),
+		sparseInput:          data.NewSparseBitset(params.InputLength),
 	}
+	config := segment.DefaultPermanenceConfig
+	config.Steps = params.PermanenceSteps
 	for i := 0; i < params.Width; i++ {
-		result.columns[i] = NewColumn(params.InputLength, params.Height)
+		result.columns[i] = NewColumnWithConfig(params.InputLength, params.Height, config)
 		result.columns[i].Index = i
 	}
 	log.HtmLogger.Printf("Region created: %+v", params)
@@ -173,13 +183,28 @@ func (l *Region) PredictedInput() data.Bitset {
 	return *dest
 }
 
+// Steps the region with an input. An empty input is a missing input, see
+// ConsumeMissing().
 func (l *Region) ConsumeInput(input data.Bitset) {
+	if input.IsZero() {
+		l.ConsumeMissing()
+		return
+	}
 	log.HtmLogger.Printf("\n============ %s Consume(learning=%t, input=%v)",
 		l.Name, l.Learning, input)
 	l.scores = l.scores[0:0]
+	sparse := data.PreferSparse(input)
+	if sparse {
+		l.sparseInput.ResetTo(input)
+	}
 	for i, c := range l.columns {
 		c.active.Reset()
-		overlapScore := c.Connected().Overlap(input)
+		var overlapScore int
+		if sparse {
+			overlapScore = l.sparseInput.Overlap(c.Connected())
+		} else {
+			overlapScore = c.Connected().Overlap(input)
+		}
 		if overlapScore >= l.MinimumInputOverlap {
 			score := float32(overlapScore) + c.Boost()
 			heap.Push(&l.scores, ScoredElement{i, score})
@@ -218,6 +243,28 @@ func (l *Region) ConsumeInput(input data.Bitset) {
 	}
 }
 
+// Steps the region without an input, e.g. for a gap in the data. No columns are
+// active, so no cells are predictive either, and nothing is learned: pending
+// segment updates are dropped. The next input starts a new sequence, as the first
+// input does.
+func (l *Region) ConsumeMissing() {
+	log.HtmLogger.Printf("\n============ %s Consume(learning=%t, missing input)",
+		l.Name, l.Learning)
+	l.scores = l.scores[0:0]
+	for _, c := range l.columns {
+		c.active.Reset()
+		c.predictive.Reset()
+		c.ResetLearning()
+	}
+	l.lastActive.ResetTo(*l.active)
+	l.active.Reset()
+	l.lastPredictive.ResetTo(*l.predictive)
+	l.predictive.Reset()
+	l.output.Reset()
+	l.learnActiveState.Reset()
+	l.learnPredictiveState.Reset()
+}
+
 func (l *Region) Output() data.Bitset {
 	return *l.output
 }
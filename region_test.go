@@ -152,6 +152,95 @@ func TestConsumeInput(t *testing.T) {
 	t.Log(output3)
 }
 
+func TestRegion_PermanenceSteps(t *testing.T) {
+	l := NewRegion(RegionParameters{
+		Name:                 "Quantized Region",
+		Learning:             true,
+		Height:               4,
+		Width:                50,
+		InputLength:          64,
+		MaximumFiringColumns: 5,
+		MinimumInputOverlap:  1,
+		PermanenceSteps:      255,
+	})
+	columnRand.Seed(0)
+	l.RandomizeColumns(32)
+	inputs := []*data.Bitset{data.NewBitset(64).Set(1, 5), data.NewBitset(64).Set(2, 6)}
+	for i := 0; i < 10; i++ {
+		l.ConsumeInput(*inputs[i%2])
+	}
+	for _, c := range l.columns {
+		if !c.proximal.Quantized() {
+			t.Fatalf("Proximal segment should be quantized: %v", c)
+		}
+		for _, g := range c.distal {
+			if g.Config().Steps != 255 {
+				t.Fatalf("Distal segments should be quantized: %v", g.Config())
+			}
+		}
+	}
+}
+
+func TestConsumeMissing(t *testing.T) {
+	l := NewRegion(RegionParameters{
+		Name:                 "Single Region",
+		Learning:             true,
+		Height:               4,
+		Width:                500,
+		InputLength:          64,
+		MaximumFiringColumns: 5,
+		MinimumInputOverlap:  2,
+	})
+	columnRand.Seed(2)
+	l.RandomizeColumns(32)
+	inputA := data.NewBitset(64).Set(1, 5)
+	inputB := data.NewBitset(64).Set(2, 10)
+	for i := 0; i < 20; i++ {
+		l.ConsumeInput(*inputA)
+		l.ConsumeInput(*inputB)
+	}
+	l.ConsumeInput(*inputA)
+	if l.PredictiveState().IsZero() {
+		t.Fatalf("A should predict B after learning.")
+	}
+	pending := func() (count int) {
+		for _, c := range l.columns {
+			hasUpdates := false
+			for _, g := range c.distal {
+				hasUpdates = hasUpdates || g.HasUpdates()
+			}
+			if c.learning >= 0 || hasUpdates {
+				count++
+			}
+		}
+		return
+	}
+	if pending() == 0 {
+		t.Fatalf("Test is broken, columns should have pending learning.")
+	}
+	l.ConsumeMissing()
+	if n := pending(); n != 0 {
+		t.Errorf("Missing input should drop pending learning, but %d columns still have it.", n)
+	}
+	if !l.ActiveState().IsZero() || !l.PredictiveState().IsZero() || !l.Output().IsZero() {
+		t.Errorf("Missing input should clear the state: active=%v, predictive=%v",
+			l.ActiveState(), l.PredictiveState())
+	}
+	if !l.LearningActiveState().IsZero() || !l.LearningPredictiveState().IsZero() {
+		t.Errorf("Missing input should start a new sequence.")
+	}
+	for i := 0; i < l.Width(); i++ {
+		if c := l.Column(i); !c.Active().IsZero() || !c.Predictive().IsZero() {
+			t.Fatalf("Column %d still has state: %v", i, c)
+		}
+	}
+	// The region picks up again after the gap.
+	l.ConsumeInput(*inputA)
+	if l.ActiveState().IsZero() || l.PredictiveState().IsZero() {
+		t.Errorf("A should be active and predict B after the gap.")
+	}
+}
+
 func BenchmarkConsumeInput500(b *testing.B) {
 	l := NewRegion(RegionParameters{
 		Name:                 "Single Region",
@@ -174,6 +263,27 @@ func BenchmarkConsumeInput500(b *testing.B) {
 	}
 }
 
+func BenchmarkConsumeDenseInput500(b *testing.B) {
+	l := NewRegion(RegionParameters{
+		Name:                 "Single Region",
+		Learning:             false,
+		Height:               32,
+		Width:                500,
+		InputLength:          2048,
+		MaximumFiringColumns: 10,
+		MinimumInputOverlap:  1,
+	})
+	l.RandomizeColumns(1024)
+
+	input := data.NewBitset(2048)
+	input.Set(columnRand.Perm(2048)[0:512]...)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.ConsumeInput(*input)
+	}
+}
+
 func BenchmarkLearnRegion(b *testing.B) {
 	l := NewRegion(RegionParameters{
 		Name:                 "Single Region",
@@ -12,10 +12,17 @@
 //
 // A permanence value is a float32 in the closed range [0.0, 1.0]. Numenta uses a
 // quantized increment/decrement strategy and there is a threshold for connection.
+//
+// Optionally, the permanence values can be quantized into a fixed number of steps
+// (see PermanenceConfiguration.Steps). Quantized values are stored as uint16 in
+// sorted slices, which takes less than half the memory of the float32 map, and
+// all learning arithmetic happens on integers, which makes learning bit-exact
+// across platforms.
 
 package segment
 
 import "fmt"
+import "math"
 import "github.com/dukejeffrie/htm/data"
 
 // Parameters for the permanence map.
@@ -37,6 +44,40 @@ type PermanenceConfiguration struct {
 
 	// The decrement to use when a synapse is weakened.
 	Decrement float32
+
+	// Number of discrete permanence steps in the range [0.0, 1.0]. Zero means
+	// permanences are stored as float32. Otherwise, permanences are stored as
+	// multiples of 1/Steps, so 255 behaves like uint8 permanences and 65535 like
+	// uint16 permanences.
+	Steps uint16
+}
+
+// Quantizes a permanence value into the number of steps given in the config.
+// Values are rounded to the nearest step and clamped to [0, Steps].
+func (c PermanenceConfiguration) quantize(v float32) uint16 {
+	// The explicit conversion prevents fused multiply-add, which would make the
+	// result platform-dependent.
+	q := math.Floor(float64(float64(v)*float64(c.Steps)) + 0.5)
+	if q < 0 {
+		return 0
+	} else if q > float64(c.Steps) {
+		return c.Steps
+	}
+	return uint16(q)
+}
+
+// Converts a quantized permanence back into a float32 value.
+func (c PermanenceConfiguration) dequantize(q uint16) float32 {
+	return float32(q) / float32(c.Steps)
+}
+
+// Quantized version of the permanence parameters, computed once per map.
+type quantizedConfiguration struct {
+	threshold uint16
+	initial   uint16
+	minimum   uint16
+	increment uint16
+	decrement uint16
 }
 
 // Default permanence configuration.
@@ -58,26 +99,61 @@ type PermanenceMap struct {
 	permanence     map[int]float32
 	synapses       *data.Bitset
 	receptiveField *data.Bitset
+
+	// Only used when config.Steps > 0, in which case permanence is nil.
+	quanta    quantizedConfiguration
+	quantized quantizedValues
 }
 
 func (pm PermanenceMap) Config() PermanenceConfiguration {
 	return pm.config
 }
 
+// Whether this map stores quantized permanence values.
+func (pm PermanenceMap) Quantized() bool {
+	return pm.config.Steps > 0
+}
+
 func NewPermanenceMap(numBits int) *PermanenceMap {
+	return NewPermanenceMapWithConfig(numBits, DefaultPermanenceConfig)
+}
+
+// Creates a new permanence map with the given configuration. If config.Steps is
+// not zero, the map stores quantized permanence values.
+func NewPermanenceMapWithConfig(numBits int, config PermanenceConfiguration) *PermanenceMap {
 	result := &PermanenceMap{
-		config:         DefaultPermanenceConfig,
-		permanence:     make(map[int]float32),
+		config:         config,
 		synapses:       data.NewBitset(numBits),
 		receptiveField: data.NewBitset(numBits),
 	}
+	if config.Steps > 0 {
+		result.quanta = quantizedConfiguration{
+			threshold: config.quantize(config.Threshold),
+			initial:   config.quantize(config.Initial),
+			minimum:   config.quantize(config.Minimum),
+			increment: config.quantize(config.Increment),
+			decrement: config.quantize(config.Decrement),
+		}
+	} else {
+		result.permanence = make(map[int]float32)
+	}
 	return result
 }
 
 func PermanenceMapFromBits(bits data.Bitset) (pm *PermanenceMap) {
-	pm = NewPermanenceMap(bits.Len())
+	return PermanenceMapFromBitsWithConfig(bits, DefaultPermanenceConfig)
+}
+
+// Creates a permanence map with the given configuration, where all bits have the
+// initial permanence.
+func PermanenceMapFromBitsWithConfig(bits data.Bitset, config PermanenceConfiguration) (pm *PermanenceMap) {
+	pm = NewPermanenceMapWithConfig(bits.Len(), config)
 	bits.Foreach(func(k int) {
-		pm.permanence[k] = pm.config.Initial
+		if pm.Quantized() {
+			pm.quantized.put(k, pm.quanta.initial)
+		} else {
+			pm.permanence[k] = pm.config.Initial
+		}
 	})
 	if pm.config.Initial > pm.config.Threshold {
 		pm.synapses.Or(bits)
@@ -86,6 +162,18 @@ func PermanenceMapFromBits(bits data.Bitset) (pm *PermanenceMap) {
 }
 
 func (pm *PermanenceMap) Reset(connected ...int) {
+	if pm.Quantized() {
+		if pm.quantized.Len() > 0 {
+			pm.quantized.reset()
+			pm.synapses.Reset()
+		}
+		for _, v := range connected {
+			pm.quantized.put(v, pm.quanta.initial)
+		}
+		pm.synapses.Set(connected...)
+		pm.receptiveField.ResetTo(*pm.synapses)
+		return
+	}
 	if len(pm.permanence) > 0 {
 		pm.permanence = make(map[int]float32)
 		pm.synapses.Reset()
@@ -102,11 +190,19 @@ func (pm PermanenceMap) Len() int {
 }
 
 func (pm *PermanenceMap) Get(k int) (v float32) {
+	if pm.Quantized() {
+		q, _ := pm.quantized.get(k)
+		return pm.config.dequantize(q)
+	}
 	v = pm.permanence[k]
 	return
 }
 
 func (pm *PermanenceMap) Set(k int, v float32) {
+	if pm.Quantized() {
+		pm.setQuantized(k, pm.config.quantize(v))
+		return
+	}
 	if v > 1.0 {
 		v = 1.0
 	} else if v < 0.0 {
@@ -127,7 +223,57 @@ func (pm *PermanenceMap) Set(k int, v float32) {
 	}
 }
 
+func (pm *PermanenceMap) setQuantized(k int, q uint16) {
+	if q > pm.config.Steps {
+		q = pm.config.Steps
+	}
+	if q < pm.quanta.minimum {
+		pm.synapses.Unset(k)
+		pm.receptiveField.Unset(k)
+		pm.quantized.remove(k)
+		return
+	}
+	pm.quantized.put(k, q)
+	pm.receptiveField.Set(k)
+	if q >= pm.quanta.threshold {
+		pm.synapses.Set(k)
+	} else {
+		pm.synapses.Unset(k)
+	}
+}
+
+// Applies fn to every quantized permanence, with the same clamping and removal
+// as setQuantized(), in a single pass.
+func (pm *PermanenceMap) updateQuantized(fn func(k int, q uint16) uint16) {
+	pm.quantized.update(func(k int, q uint16) uint16 {
+		if q = fn(k, q); q > pm.config.Steps {
+			q = pm.config.Steps
+		}
+		return q
+	}, func(k int, q uint16) bool {
+		if q < pm.quanta.minimum {
+			pm.synapses.Unset(k)
+			pm.receptiveField.Unset(k)
+			return false
+		}
+		pm.receptiveField.Set(k)
+		if q >= pm.quanta.threshold {
+			pm.synapses.Set(k)
+		} else {
+			pm.synapses.Unset(k)
+		}
+		return true
+	})
+}
+
 func (pm PermanenceMap) String() string {
+	if pm.Quantized() {
+		return fmt.Sprintf("(%d/%dconnected, %d steps, %+v)",
+			pm.synapses.NumSetBits(),
+			pm.receptiveField.NumSetBits(),
+			pm.config.Steps,
+			pm.quantized)
+	}
 	return fmt.Sprintf("(%d/%dconnected, %+v)",
 		pm.synapses.NumSetBits(),
 		pm.receptiveField.NumSetBits(),
@@ -151,6 +297,15 @@ func (pm PermanenceMap) Overlap(input data.Bitset, weak bool) int {
 }
 
 func (pm *PermanenceMap) narrow(input data.Bitset) {
+	if pm.Quantized() {
+		pm.updateQuantized(func(k int, q uint16) uint16 {
+			if input.IsSet(k) {
+				return saturatingAdd(q, pm.quanta.increment, pm.config.Steps)
+			}
+			return saturatingSub(q, pm.quanta.decrement)
+		})
+		return
+	}
 	for k, v := range pm.permanence {
 		if input.IsSet(k) {
 			v += pm.config.Increment
@@ -162,6 +317,15 @@ func (pm *PermanenceMap) narrow(input data.Bitset) {
 }
 
 func (pm *PermanenceMap) weaken(input data.Bitset) {
+	if pm.Quantized() {
+		pm.updateQuantized(func(k int, q uint16) uint16 {
+			if input.IsSet(k) {
+				return saturatingSub(q, pm.quanta.decrement)
+			}
+			return q
+		})
+		return
+	}
 	for k, v := range pm.permanence {
 		if input.IsSet(k) {
 			v -= pm.config.Decrement
@@ -169,3 +333,34 @@ func (pm *PermanenceMap) weaken(input data.Bitset) {
 		pm.Set(k, v)
 	}
 }
+
+// Multiplies all permanence values by the given factor.
+func (pm *PermanenceMap) scale(factor float32) {
+	if pm.Quantized() {
+		pm.updateQuantized(func(k int, q uint16) uint16 {
+			scaled := math.Floor(float64(float64(q)*float64(factor)) + 0.5)
+			if scaled > float64(pm.config.Steps) {
+				scaled = float64(pm.config.Steps)
+			}
+			return uint16(scaled)
+		})
+		return
+	}
+	for k, v := range pm.permanence {
+		pm.Set(k, v*factor)
+	}
+}
+
+func saturatingAdd(q, d, max uint16) uint16 {
+	if q > max-d {
+		return max
+	}
+	return q + d
+}
+
+func saturatingSub(q, d uint16) uint16 {
+	if q < d {
+		return 0
+	}
+	return q - d
+}
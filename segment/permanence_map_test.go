@@ -1,5 +1,7 @@
 package segment
 
+import "fmt"
+import "runtime"
 import "testing"
 import "github.com/dukejeffrie/htm/data"
 
@@ -77,3 +79,139 @@ func TestWeakenSynapses(t *testing.T) {
 		t.Error("Should not be connected @30:", *pm)
 	}
 }
+
+func TestQuantizedPermanence(t *testing.T) {
+	config := DefaultPermanenceConfig
+	config.Steps = 255
+	pm := NewPermanenceMapWithConfig(64, config)
+	if !pm.Quantized() {
+		t.Fatal("Should be quantized:", *pm)
+	}
+	pm.Reset(1, 3, 5)
+	if pm.Get(1) != float32(config.quantize(config.Initial))/255 {
+		t.Errorf("Initial permanence should be quantized: %v", *pm)
+	}
+	pm.Set(8, pm.Config().Threshold)
+	if !pm.Connected().IsSet(8) {
+		t.Errorf("Should have connected bit 8: %v", *pm)
+	}
+	pm.Set(8, pm.Config().Minimum-0.01)
+	if pm.ReceptiveField().IsSet(8) {
+		t.Errorf("Should have removed bit 8: %v", *pm)
+	}
+	pm.Set(10, 2.0)
+	if q, _ := pm.quantized.get(10); q != 255 {
+		t.Errorf("Permanence should be clamped to %d: %v", 255, *pm)
+	}
+}
+
+func TestQuantizedNarrowSynapses(t *testing.T) {
+	config := DefaultPermanenceConfig
+	config.Steps = 255
+	pm := NewPermanenceMapWithConfig(64, config)
+	pm.Reset(1, 3, 5, 8, 13)
+	initial, _ := pm.quantized.get(1)
+	input := data.NewBitset(64).Set(1, 5, 22)
+	pm.narrow(*input)
+	pm.narrow(*input)
+	if q, _ := pm.quantized.get(1); q != initial+2*pm.quanta.increment {
+		t.Errorf("Permanence should have increased by exactly two steps: %v", *pm)
+	}
+	q1, _ := pm.quantized.get(1)
+	if q5, _ := pm.quantized.get(5); q1 != q5 {
+		t.Errorf("Permanence scores must be uniform: %v", *pm)
+	}
+	if _, ok := pm.quantized.get(22); ok {
+		t.Errorf("Permanence for non-connected should be zero: %v", *pm)
+	}
+	if pm.Connected().NumSetBits() != 2 {
+		t.Errorf("Should have kept only 2 connections: %v", *pm)
+	}
+	pm.weaken(*input)
+	pm.weaken(*input)
+	if q, _ := pm.quantized.get(1); q != initial {
+		t.Errorf("Permanence should be back to exactly %d: %v", initial, *pm)
+	}
+}
+
+func TestQuantizedSaturation(t *testing.T) {
+	config := DefaultPermanenceConfig
+	config.Steps = 65535
+	pm := NewPermanenceMapWithConfig(64, config)
+	pm.Reset(1)
+	input := data.NewBitset(64).Set(1)
+	for i := 0; i < 20; i++ {
+		pm.narrow(*input)
+	}
+	if pm.Get(1) != 1.0 {
+		t.Errorf("Permanence should saturate at 1.0: %v", *pm)
+	}
+	pm.scale(1.01)
+	if q, _ := pm.quantized.get(1); q != 65535 {
+		t.Errorf("Permanence should not overflow: %v", *pm)
+	}
+}
+
+// Returns the heap bytes per synapse of permanence maps with the given
+// configuration, 2048 bits and 512 synapses.
+func permanenceMapBytes(config PermanenceConfiguration) float64 {
+	const maps, synapses = 100, 512
+	result := make([]*PermanenceMap, maps)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := range result {
+		result[i] = NewPermanenceMapWithConfig(2048, config)
+		for k := 0; k < synapses; k++ {
+			result[i].Set((k*797)%2048, config.Initial)
+		}
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(result)
+	return float64(after.HeapAlloc-before.HeapAlloc) / (maps * synapses)
+}
+
+func TestQuantizedPermanence_Size(t *testing.T) {
+	config := DefaultPermanenceConfig
+	floats := permanenceMapBytes(config)
+	config.Steps = 255
+	quantized := permanenceMapBytes(config)
+	if quantized > floats/2 {
+		t.Errorf("Quantized maps should take at most half the memory: %.1f vs %.1f bytes/synapse",
+			quantized, floats)
+	}
+}
+
+func BenchmarkPermanenceMapSize(b *testing.B) {
+	for _, steps := range []uint16{0, 255} {
+		config := DefaultPermanenceConfig
+		config.Steps = steps
+		b.Run(fmt.Sprintf("steps=%d", steps), func(b *testing.B) {
+			var size float64
+			for i := 0; i < b.N; i++ {
+				size = permanenceMapBytes(config)
+			}
+			b.ReportMetric(size, "B/synapse")
+		})
+	}
+}
+
+func TestQuantizedValues(t *testing.T) {
+	var qv quantizedValues
+	for _, k := range []int{30, 10, 20, 10} {
+		qv.put(k, uint16(k))
+	}
+	if s := qv.String(); s != "map[10:10 20:20 30:30]" {
+		t.Errorf("Values should be sorted and unique: %s", s)
+	}
+	qv.remove(20)
+	qv.remove(25)
+	if _, ok := qv.get(20); ok || qv.Len() != 2 {
+		t.Errorf("Should have removed 20: %v", qv)
+	}
+	qv.update(func(k int, q uint16) uint16 { return q + 1 }, func(k int, q uint16) bool { return k != 10 })
+	if s := qv.String(); s != "map[30:31]" {
+		t.Errorf("Bad update: %s", s)
+	}
+}
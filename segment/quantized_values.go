@@ -0,0 +1,87 @@
+package segment
+
+import "bytes"
+import "fmt"
+import "sort"
+
+// Quantized permanence values, stored as two parallel slices sorted by bit index.
+// Each synapse takes 6 bytes (an int32 index and a uint16 value), instead of the
+// 12 bytes of key and value plus the per-entry overhead of a map[int]float32.
+// Lookups are binary searches; learning walks the slices in order.
+type quantizedValues struct {
+	indices []int32
+	values  []uint16
+}
+
+func (qv quantizedValues) Len() int {
+	return len(qv.indices)
+}
+
+// Returns the position of bit k, or where it would be inserted.
+func (qv quantizedValues) find(k int) (int, bool) {
+	i := sort.Search(len(qv.indices), func(i int) bool {
+		return int(qv.indices[i]) >= k
+	})
+	return i, i < len(qv.indices) && int(qv.indices[i]) == k
+}
+
+// Returns the value of bit k, with the ok idiom.
+func (qv quantizedValues) get(k int) (uint16, bool) {
+	if i, ok := qv.find(k); ok {
+		return qv.values[i], true
+	}
+	return 0, false
+}
+
+func (qv *quantizedValues) put(k int, q uint16) {
+	i, ok := qv.find(k)
+	if ok {
+		qv.values[i] = q
+		return
+	}
+	qv.indices = append(qv.indices, 0)
+	qv.values = append(qv.values, 0)
+	copy(qv.indices[i+1:], qv.indices[i:])
+	copy(qv.values[i+1:], qv.values[i:])
+	qv.indices[i], qv.values[i] = int32(k), q
+}
+
+func (qv *quantizedValues) remove(k int) {
+	if i, ok := qv.find(k); ok {
+		qv.indices = append(qv.indices[:i], qv.indices[i+1:]...)
+		qv.values = append(qv.values[:i], qv.values[i+1:]...)
+	}
+}
+
+func (qv *quantizedValues) reset() {
+	qv.indices = qv.indices[:0]
+	qv.values = qv.values[:0]
+}
+
+// Replaces every value with fn(k, value), in index order, and removes the bits
+// for which keep() is false.
+func (qv *quantizedValues) update(fn func(k int, q uint16) uint16, keep func(k int, q uint16) bool) {
+	n := 0
+	for i, idx := range qv.indices {
+		q := fn(int(idx), qv.values[i])
+		if keep(int(idx), q) {
+			qv.indices[n], qv.values[n] = idx, q
+			n++
+		}
+	}
+	qv.indices = qv.indices[:n]
+	qv.values = qv.values[:n]
+}
+
+func (qv quantizedValues) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("map[")
+	for i, idx := range qv.indices {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		fmt.Fprintf(&buf, "%d:%d", idx, qv.values[i])
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
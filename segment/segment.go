@@ -29,8 +29,13 @@ func (ds DendriteSegment) String() string {
 }
 
 func NewDendriteSegment(numBits int) *DendriteSegment {
+	return NewDendriteSegmentWithConfig(numBits, DefaultPermanenceConfig)
+}
+
+// Creates a new dendrite segment whose permanences follow the given configuration.
+func NewDendriteSegmentWithConfig(numBits int, config PermanenceConfiguration) *DendriteSegment {
 	ds := &DendriteSegment{
-		PermanenceMap:     NewPermanenceMap(numBits),
+		PermanenceMap:     NewPermanenceMapWithConfig(numBits, config),
 		MinActivityRatio:  0.02,
 		Boost:             0,
 		overlapHistory:    data.NewCycleHistory(1000),
@@ -69,9 +74,7 @@ func (ds *DendriteSegment) broaden(input data.Bitset, minOverlap int) (overlapCo
 	})
 	ds.overlapHistory.Record(overlapCount >= minOverlap)
 	if avg, ok := ds.overlapHistory.Average(); ok && avg < ds.MinActivityRatio {
-		for k, v := range ds.permanence {
-			ds.Set(k, v*1.01)
-		}
+		ds.scale(1.01)
 	}
 	return
 }
@@ -100,6 +103,8 @@ func NewSegmentUpdate(pos int, state *data.Bitset) *SegmentUpdate {
 type DistalSegmentGroup struct {
 	segments []*DistalSegment
 	updates  []*SegmentUpdate
+	// Configuration for the permanence maps of new segments.
+	config PermanenceConfiguration
 }
 
 func (g DistalSegmentGroup) String() string {
@@ -112,12 +117,24 @@ func (g DistalSegmentGroup) String() string {
 }
 
 func NewDistalSegmentGroup() *DistalSegmentGroup {
+	return NewDistalSegmentGroupWithConfig(DefaultPermanenceConfig)
+}
+
+// Creates a new distal segment group whose segments follow the given permanence
+// configuration.
+func NewDistalSegmentGroupWithConfig(config PermanenceConfiguration) *DistalSegmentGroup {
 	return &DistalSegmentGroup{
 		segments: make([]*DistalSegment, 0, 15),
 		updates:  make([]*SegmentUpdate, 0, 10),
+		config:   config,
 	}
 }
 
+// The permanence configuration of new segments in this group.
+func (g DistalSegmentGroup) Config() PermanenceConfiguration {
+	return g.config
+}
+
 func (g DistalSegmentGroup) ComputeActive(activeState data.Bitset, minOverlap int, weak bool) (resultIndex, resultOverlap int) {
 	resultIndex = -1
 	resultOverlap = minOverlap - 1
@@ -134,7 +151,7 @@ func (g DistalSegmentGroup) ComputeActive(activeState data.Bitset, minOverlap in
 
 func (g DistalSegmentGroup) HasActiveSegment(activeState data.Bitset, minOverlap int) bool {
 	for _, s := range g.segments {
-		if s.Connected().Overlap(activeState) >= minOverlap {
+		if s.synapses.Overlap(activeState) >= minOverlap {
 			return true
 		}
 	}
@@ -168,6 +185,11 @@ func (g DistalSegmentGroup) HasUpdates() bool {
 	return len(g.updates) > 0
 }
 
+// Drops the pending updates without applying them.
+func (g *DistalSegmentGroup) ClearUpdates() {
+	g.updates = g.updates[0:0]
+}
+
 func (g *DistalSegmentGroup) ApplyAll(positive bool) {
 	for _, u := range g.updates {
 		g.Apply(u, positive)
@@ -179,7 +201,7 @@ func (g *DistalSegmentGroup) Apply(update *SegmentUpdate, positive bool) {
 	var s *DistalSegment
 	if update.pos == -1 {
 		s = &DistalSegment{
-			PermanenceMap: PermanenceMapFromBits(*update.bitsToUpdate),
+			PermanenceMap: PermanenceMapFromBitsWithConfig(*update.bitsToUpdate, g.config),
 		}
 		g.segments = append(g.segments, s)
 	} else {
@@ -107,3 +107,34 @@ func TestDistalSegmentGroup(t *testing.T) {
 		t.Errorf("Unexpected active segment. Expected: %d, but got: %d. %v", 0, sIndex, *group)
 	}
 }
+
+func TestDistalSegmentGroup_Quantized(t *testing.T) {
+	config := DefaultPermanenceConfig
+	config.Steps = 255
+	group := NewDistalSegmentGroupWithConfig(config)
+	group.AddUpdate(group.CreateUpdate(-1, *data.NewBitset(64).Set(1, 10), 2))
+	group.ApplyAll(true)
+	s := group.Segment(0)
+	if !s.Quantized() {
+		t.Fatalf("New segments should use the group's config: %v", s)
+	}
+	if v, expected := s.Get(10), config.dequantize(config.quantize(config.Initial)); v != expected {
+		t.Errorf("Bad initial permanence. Expected: %f, but got: %f", expected, v)
+	}
+	if !s.Connected().IsSet(1) || !s.Connected().IsSet(10) {
+		t.Errorf("New synapses should be connected: %v", s)
+	}
+}
+
+func TestNewDendriteSegmentWithConfig(t *testing.T) {
+	config := DefaultPermanenceConfig
+	config.Steps = 65535
+	ds := NewDendriteSegmentWithConfig(64, config)
+	if !ds.Quantized() {
+		t.Errorf("Segment should be quantized: %v", ds)
+	}
+	ds.Learn(*data.NewBitset(64).Set(3, 4), false, 1)
+	if ds.ReceptiveField().NumSetBits() != 2 {
+		t.Errorf("Segment should have learned 2 synapses: %v", ds)
+	}
+}